@@ -0,0 +1,214 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrDetached The detach key sequence was typed into an attach session
+var ErrDetached = errors.New("detached")
+
+// AttachConfig Tune an attach session, zero values use the defaults
+type AttachConfig struct {
+	Interval   time.Duration // tail poll interval, default 200ms
+	TailBytes  int           // max bytes per tail call, default 64KB
+	DetachKeys string        // input ending the session, default Ctrl-]
+	Echo       bool          // echo the edited line to the output, for terminals in raw mode
+}
+
+// AttachSession An interactive session with a process like supervisorctl fg.
+// Read returns the merged stdout and stderr output written since the session
+// started. Write takes terminal input: it is edited locally (backspace, Ctrl-U)
+// and every completed line is sent to the process stdin. Output is only
+// produced while someone reads, a stalled reader stalls the tail.
+type AttachSession struct {
+	client *Client
+	name   string
+	config AttachConfig
+	stdin  *StdinWriter
+	reader *io.PipeReader
+	writer *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	line     []byte
+	matched  int // bytes of DetachKeys matched so far
+	lastCR   bool
+	detached bool
+}
+
+// Attach Start an attach session with name, its output is tailed from the
+// current end of the logs until ctx is done, the session is closed or detached
+func (c *Client) Attach(ctx context.Context, name string, config AttachConfig) (*AttachSession, error) {
+	if config.Interval <= 0 {
+		config.Interval = 200 * time.Millisecond
+	}
+	if config.TailBytes <= 0 {
+		config.TailBytes = 64 * 1024
+	}
+	if config.DetachKeys == "" {
+		config.DetachKeys = "\x1d"
+	}
+	offsets := make(map[LogStream]int64)
+	for _, stream := range []LogStream{StdoutStream, StderrStream} {
+		size, err := c.ProcessLogSize(name, stream)
+		if err != nil {
+			return nil, err
+		}
+		offsets[stream] = size
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	s := &AttachSession{
+		client: c,
+		name:   name,
+		config: config,
+		stdin:  c.ProcessStdin(name),
+		reader: reader,
+		writer: writer,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.tail(ctx, offsets)
+	return s, nil
+}
+
+// Read Read process output, io.EOF once the session ended
+func (s *AttachSession) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Write Feed terminal input to the line editor. When the detach keys are typed
+// the session ends and ErrDetached is returned with the bytes consumed so far.
+func (s *AttachSession) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.detached {
+		return 0, ErrDetached
+	}
+	keys := s.config.DetachKeys
+	for i, b := range p {
+		if b == keys[s.matched] {
+			s.matched++
+			if s.matched == len(keys) {
+				s.detached = true
+				s.Close()
+				return i + 1, ErrDetached
+			}
+			continue
+		}
+		// a partial detach sequence turned out to be input
+		pending := keys[:s.matched]
+		s.matched = 0
+		for j := 0; j < len(pending); j++ {
+			if err := s.edit(pending[j]); err != nil {
+				return i, err
+			}
+		}
+		if b == keys[0] {
+			s.matched = 1
+			continue
+		}
+		if err := s.edit(b); err != nil {
+			return i, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close End the session, pending Reads return io.EOF
+func (s *AttachSession) Close() error {
+	s.cancel()
+	s.writer.Close()
+	<-s.done
+	return s.stdin.Close()
+}
+
+// Done Return a channel closed when the session ended
+func (s *AttachSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// edit Apply one input byte to the current line
+func (s *AttachSession) edit(b byte) error {
+	lastCR := s.lastCR
+	s.lastCR = b == '\r'
+	switch {
+	case b == '\n' && lastCR:
+		// the second half of a CRLF
+	case b == '\r' || b == '\n':
+		line := string(s.line) + "\n"
+		s.line = s.line[:0]
+		s.echo("\r\n")
+		_, err := io.WriteString(s.stdin, line)
+		return err
+	case b == 0x7f || b == '\b':
+		if len(s.line) > 0 {
+			_, size := utf8.DecodeLastRune(s.line)
+			s.line = s.line[:len(s.line)-size]
+			s.echo("\b \b")
+		}
+	case b == 0x15: // Ctrl-U
+		s.echo(strings.Repeat("\b \b", utf8.RuneCount(s.line)))
+		s.line = s.line[:0]
+	case b < 0x20 && b != '\t':
+		// other control characters are dropped
+	default:
+		s.line = append(s.line, b)
+		s.echo(string(b))
+	}
+	return nil
+}
+
+func (s *AttachSession) echo(text string) {
+	if s.config.Echo && text != "" {
+		io.WriteString(s.writer, text)
+	}
+}
+
+// tail Copy new stdout and stderr output to the pipe until ctx is done
+func (s *AttachSession) tail(ctx context.Context, offsets map[LogStream]int64) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		for _, stream := range []LogStream{StdoutStream, StderrStream} {
+			tail, err := s.client.TailProcessLog(s.name, stream, int(offsets[stream]), s.config.TailBytes)
+			if err != nil {
+				s.writer.CloseWithError(err)
+				return
+			}
+			content := newTailContent(tail, offsets[stream])
+			offsets[stream] = tail.Offset
+			if content == "" {
+				continue
+			}
+			if _, err := io.WriteString(s.writer, content); err != nil {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			s.writer.Close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newTailContent Return the part of a tail from offset that was not shown yet.
+// supervisord answers with the last length bytes of the log, including bytes
+// before offset, unless it overflowed and skipped output.
+func newTailContent(tail *TailResult, offset int64) string {
+	content := tail.Content
+	if n := tail.Offset - offset; !tail.Overflow && n >= 0 && n < int64(len(content)) {
+		content = content[int64(len(content))-n:]
+	}
+	return content
+}
@@ -0,0 +1,65 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAttachSession(t *testing.T) {
+	var mu sync.Mutex
+	stdout, sent := "old output\n", make([]string, 0)
+	tail := func(args []interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return supervisordTail(stdout, int(args[1].(int64)), int(args[2].(int64))), nil
+	}
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.tailProcessStdoutLog": tail,
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", 0, false}, nil
+		},
+		"supervisor.sendProcessStdin": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, args[1].(string))
+			stdout += "> " + args[1].(string)
+			return true, nil
+		},
+	})
+	session, err := ts.client(t).Attach(context.Background(), "web", AttachConfig{Interval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(session)
+		output <- string(data)
+	}()
+	if _, err := session.Write([]byte("ab\x7fc\r")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Write([]byte("xyz\x15q\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	n, err := session.Write([]byte("\x1dignored"))
+	if !errors.Is(err, ErrDetached) || n != 1 {
+		t.Fatalf("expected to detach after 1 byte but %d %v", n, err)
+	}
+	if got := <-output; got != "> ac\n> q\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 || sent[0] != "ac\n" || sent[1] != "q\n" {
+		t.Fatalf("unexpected input %q", sent)
+	}
+	if _, err := session.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected EOF after detach but %v", err)
+	}
+}
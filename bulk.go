@@ -0,0 +1,179 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProcessError The fault one process of a bulk operation ended with
+type ProcessError struct {
+	Name  string // group:name
+	Fault *Fault
+}
+
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Name, e.Fault.Code, e.Fault.String)
+}
+
+// Unwrap Return the fault, so errors.Is(err, ErrNotRunning) works per process
+func (e *ProcessError) Unwrap() error {
+	return e.Fault
+}
+
+// BulkError The processes a bulk operation failed for
+type BulkError struct {
+	Total  int // processes in the operation
+	errors []error
+}
+
+func (e *BulkError) Error() string {
+	messages := make([]string, 0, len(e.errors))
+	for _, err := range e.errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d of %d processes failed: %s", len(e.errors), e.Total, strings.Join(messages, "; "))
+}
+
+// Errors Return a *ProcessError per failed process
+func (e *BulkError) Errors() []error {
+	return append([]error(nil), e.errors...)
+}
+
+// Unwrap Return the per process errors. The errors package of this module's
+// Go version does not call it, errors.Is and errors.As use Is and As below.
+func (e *BulkError) Unwrap() []error {
+	return e.Errors()
+}
+
+// Is Report whether any process failed with target
+func (e *BulkError) Is(target error) bool {
+	for _, err := range e.errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As Find the first process error matching target
+func (e *BulkError) As(target interface{}) bool {
+	for _, err := range e.errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// BulkResult The statuses of a bulk operation split by outcome
+type BulkResult struct {
+	Succeeded []ActionStatus
+	Failed    []ActionStatus
+	Skipped   []ActionStatus // failed with a benign code, e.g. ALREADY_STARTED for a start
+}
+
+// NewBulkResult Partition statuses, codes in benign count as skipped instead of failed
+func NewBulkResult(statuses []ActionStatus, benign ...Status) *BulkResult {
+	result := &BulkResult{}
+	for _, status := range statuses {
+		switch {
+		case status.Status == StatusSuccess:
+			result.Succeeded = append(result.Succeeded, status)
+		case containsStatus(benign, status.Status):
+			result.Skipped = append(result.Skipped, status)
+		default:
+			result.Failed = append(result.Failed, status)
+		}
+	}
+	return result
+}
+
+// OK Report whether no process failed
+func (r *BulkResult) OK() bool {
+	return len(r.Failed) == 0
+}
+
+// Err Return a *BulkError when any process failed, nil otherwise
+func (r *BulkResult) Err() error {
+	if r.OK() {
+		return nil
+	}
+	e := &BulkError{Total: len(r.Succeeded) + len(r.Failed) + len(r.Skipped)}
+	for _, status := range r.Failed {
+		e.errors = append(e.errors, &ProcessError{
+			Name:  status.Group + ":" + status.Name,
+			Fault: &Fault{Code: status.Status, String: status.Description},
+		})
+	}
+	return e
+}
+
+// NoBenign Pass as benign codes, e.g. StartAllProcessesBulk(true, NoBenign...), to
+// count every code but SUCCESS as a failure. Passing no codes means the defaults.
+var NoBenign = []Status{}
+
+func containsStatus(statuses []Status, status Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// bulk Build the result of a bulk call, the error is the call error or the
+// *BulkError of the failed processes. Nil benign codes means defaults, an empty
+// slice like NoBenign means none.
+func bulk(statuses []ActionStatus, err error, benign []Status, defaults ...Status) (*BulkResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	if benign == nil {
+		benign = defaults
+	}
+	result := NewBulkResult(statuses, benign...)
+	return result, result.Err()
+}
+
+// StartProcessGroupBulk StartProcessGroup, ALREADY_STARTED is benign unless benign codes are given
+func (c *Client) StartProcessGroupBulk(name string, wait bool, benign ...Status) (*BulkResult, error) {
+	statuses, err := c.StartProcessGroup(name, wait)
+	return bulk(statuses, err, benign, StatusAlreadyStarted)
+}
+
+// StartAllProcessesBulk StartAllProcesses, ALREADY_STARTED is benign unless benign codes are given
+func (c *Client) StartAllProcessesBulk(wait bool, benign ...Status) (*BulkResult, error) {
+	statuses, err := c.StartAllProcesses(wait)
+	return bulk(statuses, err, benign, StatusAlreadyStarted)
+}
+
+// StopProcessGroupBulk StopProcessGroup, NOT_RUNNING is benign unless benign codes are given
+func (c *Client) StopProcessGroupBulk(name string, wait bool, benign ...Status) (*BulkResult, error) {
+	statuses, err := c.StopProcessGroup(name, wait)
+	return bulk(statuses, err, benign, StatusNotRunning)
+}
+
+// StopAllProcessesBulk StopAllProcesses, NOT_RUNNING is benign unless benign codes are given
+func (c *Client) StopAllProcessesBulk(wait bool, benign ...Status) (*BulkResult, error) {
+	statuses, err := c.StopAllProcesses(wait)
+	return bulk(statuses, err, benign, StatusNotRunning)
+}
+
+// SignalProcessGroupBulk SignalProcessGroupNamed, NOT_RUNNING is benign unless benign codes are given
+func (c *Client) SignalProcessGroupBulk(name string, signal Signal, benign ...Status) (*BulkResult, error) {
+	statuses, err := c.SignalProcessGroupNamed(name, signal)
+	return bulk(statuses, err, benign, StatusNotRunning)
+}
+
+// SignalAllProcessesBulk SignalAllProcessesNamed, NOT_RUNNING is benign unless benign codes are given
+func (c *Client) SignalAllProcessesBulk(signal Signal, benign ...Status) (*BulkResult, error) {
+	statuses, err := c.SignalAllProcessesNamed(signal)
+	return bulk(statuses, err, benign, StatusNotRunning)
+}
+
+// ClearAllProcessLogsBulk ClearAllProcessLogs, no code is benign unless benign codes are given
+func (c *Client) ClearAllProcessLogsBulk(benign ...Status) (*BulkResult, error) {
+	statuses, err := c.ClearAllProcessLogs()
+	return bulk(statuses, err, benign)
+}
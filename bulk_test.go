@@ -0,0 +1,56 @@
+package supervisor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBulkResult(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.startAllProcesses": func(args []interface{}) (interface{}, error) {
+			return []ActionStatus{
+				{Name: "web", Group: "web", Status: StatusSuccess, Description: "OK"},
+				{Name: "cron", Group: "jobs", Status: StatusAlreadyStarted, Description: "ALREADY_STARTED"},
+				{Name: "worker", Group: "jobs", Status: StatusSpawnError, Description: "SPAWN_ERROR: worker"},
+			}, nil
+		},
+	})
+	cli := ts.client(t)
+	result, err := cli.StartAllProcessesBulk(true)
+	if len(result.Succeeded) != 1 || len(result.Skipped) != 1 || len(result.Failed) != 1 || result.OK() {
+		t.Fatalf("unexpected partitions %+v", result)
+	}
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) || bulkErr.Total != 3 || len(bulkErr.Errors()) != 1 {
+		t.Fatalf("expected a bulk error but %v", err)
+	}
+	var processErr *ProcessError
+	if !errors.As(err, &processErr) || processErr.Name != "jobs:worker" || processErr.Fault.Code != StatusSpawnError {
+		t.Fatalf("expected the worker fault but %v", processErr)
+	}
+	if errors.Is(err, ErrNotRunning) {
+		t.Fatal("no process failed with NOT_RUNNING")
+	}
+
+	// benign codes given per call replace the defaults
+	result, err = cli.StartAllProcessesBulk(true, StatusSpawnError)
+	if err == nil || len(result.Failed) != 1 || result.Failed[0].Name != "cron" {
+		t.Fatalf("expected only cron to fail but %+v %v", result, err)
+	}
+	result, err = cli.StartAllProcessesBulk(true, NoBenign...)
+	if err == nil || len(result.Failed) != 2 || len(result.Skipped) != 0 {
+		t.Fatalf("expected cron and worker to fail without benign codes but %+v %v", result, err)
+	}
+}
+
+func TestBulkErrorIs(t *testing.T) {
+	err := NewBulkResult([]ActionStatus{
+		{Name: "web", Group: "web", Status: StatusNotRunning, Description: "NOT_RUNNING"},
+	}).Err()
+	if !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected to match ErrNotRunning: %v", err)
+	}
+	if err.Error() != "1 of 1 processes failed: web:web: NOT_RUNNING: NOT_RUNNING" {
+		t.Fatalf("unexpected message %q", err)
+	}
+}
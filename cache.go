@@ -0,0 +1,204 @@
+package supervisor
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheConfig The TTL of each cached call, zero uses the default and a negative
+// TTL disables caching of that call
+type CacheConfig struct {
+	ConfigTTL  time.Duration // GetAllConfigInfo, default 1 minute
+	ProcessTTL time.Duration // GetAllProcessInfo, default 1 second
+	MethodsTTL time.Duration // ListMethods, default 10 minutes
+	VersionTTL time.Duration // GetAPIVersion and GetSupervisorVersion, default 10 minutes
+}
+
+// WithCache Cache the results of GetAllConfigInfo, GetAllProcessInfo,
+// ListMethods and the version calls. Concurrent fetches of one result share a
+// single request, and calls that change processes or the config invalidate
+// the results they affect.
+func WithCache(config CacheConfig) Option {
+	return func(c *Client) {
+		c.cache = newCallCache(config)
+	}
+}
+
+// readOnlyMethods Calls that never invalidate the cache
+var readOnlyMethods = map[string]bool{
+	"system.listMethods":              true,
+	"system.methodHelp":               true,
+	"system.methodSignature":          true,
+	"supervisor.getAPIVersion":        true,
+	"supervisor.getVersion":           true,
+	"supervisor.getSupervisorVersion": true,
+	"supervisor.getIdentification":    true,
+	"supervisor.getState":             true,
+	"supervisor.getPID":               true,
+	"supervisor.readLog":              true,
+	"supervisor.readMainLog":          true,
+	"supervisor.getAllConfigInfo":     true,
+	"supervisor.getProcessInfo":       true,
+	"supervisor.getAllProcessInfo":    true,
+	"supervisor.readProcessLog":       true,
+	"supervisor.readProcessStdoutLog": true,
+	"supervisor.readProcessStderrLog": true,
+	"supervisor.tailProcessLog":       true,
+	"supervisor.tailProcessStdoutLog": true,
+	"supervisor.tailProcessStderrLog": true,
+}
+
+// processMethods Calls that only change process state, everything else that is
+// not read-only may change the config or the server and clears the whole cache
+var processMethods = map[string]bool{
+	"supervisor.startProcess":        true,
+	"supervisor.startProcessGroup":   true,
+	"supervisor.startAllProcesses":   true,
+	"supervisor.stopProcess":         true,
+	"supervisor.stopProcessGroup":    true,
+	"supervisor.stopAllProcesses":    true,
+	"supervisor.signalProcess":       true,
+	"supervisor.signalProcessGroup":  true,
+	"supervisor.signalAllProcesses":  true,
+	"supervisor.sendProcessStdin":    true,
+	"supervisor.clearLog":            true,
+	"supervisor.clearProcessLog":     true,
+	"supervisor.clearProcessLogs":    true,
+	"supervisor.clearAllProcessLogs": true,
+}
+
+// callCache A TTL cache of argument-less calls with single-flight fetches
+type callCache struct {
+	mu         sync.Mutex
+	ttls       map[string]time.Duration
+	entries    map[string]*cacheEntry
+	generation uint64 // bumped by every invalidation
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	done    chan struct{} // closed once the fetch finished
+	value   reflect.Value
+	err     error
+	expires time.Time
+}
+
+func newCallCache(config CacheConfig) *callCache {
+	ttl := func(d, def time.Duration) time.Duration {
+		if d == 0 {
+			return def
+		}
+		return d
+	}
+	methods := ttl(config.MethodsTTL, 10*time.Minute)
+	version := ttl(config.VersionTTL, 10*time.Minute)
+	return &callCache{
+		ttls: map[string]time.Duration{
+			"supervisor.getAllConfigInfo":     ttl(config.ConfigTTL, time.Minute),
+			"supervisor.getAllProcessInfo":    ttl(config.ProcessTTL, time.Second),
+			"system.listMethods":              methods,
+			"supervisor.getAPIVersion":        version,
+			"supervisor.getSupervisorVersion": version,
+		},
+		entries: make(map[string]*cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// cacheable Report whether the result of method is cached
+func (cc *callCache) cacheable(method string) bool {
+	return cc.ttls[method] > 0
+}
+
+// get Copy the cached result of method into relay, fetching it when missing or
+// expired. Callers arriving during a fetch wait for it instead of sending their own.
+func (cc *callCache) get(method string, relay interface{}, fetch func(relay interface{}) error) error {
+	target := reflect.ValueOf(relay)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fetch(relay)
+	}
+	key := method + " " + target.Type().String()
+	cc.mu.Lock()
+	entry, ok := cc.entries[key]
+	if ok {
+		select {
+		case <-entry.done:
+			if entry.err != nil || !cc.now().Before(entry.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+	if ok {
+		cc.mu.Unlock()
+		<-entry.done
+		if entry.err != nil {
+			return entry.err
+		}
+		target.Elem().Set(cloneValue(entry.value))
+		return nil
+	}
+	entry = &cacheEntry{done: make(chan struct{})}
+	cc.entries[key] = entry
+	generation := cc.generation
+	cc.mu.Unlock()
+
+	err := fetch(relay)
+
+	cc.mu.Lock()
+	entry.err = err
+	if err == nil {
+		entry.value = cloneValue(target.Elem())
+		entry.expires = cc.now().Add(cc.ttls[method])
+	}
+	if generation != cc.generation && cc.entries[key] == entry {
+		// invalidated while fetching, share the result with the waiters only
+		delete(cc.entries, key)
+	}
+	close(entry.done)
+	cc.mu.Unlock()
+	return err
+}
+
+// invalidate Drop the results a call of method may have changed
+func (cc *callCache) invalidate(method string) {
+	if readOnlyMethods[method] {
+		return
+	}
+	if processMethods[method] {
+		cc.drop("supervisor.getAllProcessInfo")
+		return
+	}
+	cc.drop()
+}
+
+// drop Forget the results of methods, all results when none are given
+func (cc *callCache) drop(methods ...string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.generation++
+	for key := range cc.entries {
+		if len(methods) == 0 || containsString(methods, key[:strings.IndexByte(key, ' ')]) {
+			delete(cc.entries, key)
+		}
+	}
+}
+
+// cloneValue Copy slices so callers cannot modify the cached result
+func cloneValue(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		return v
+	}
+	clone := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(clone, v)
+	return clone
+}
+
+// InvalidateCache Forget every cached result, a no-op without WithCache
+func (c *Client) InvalidateCache() {
+	if c.cache != nil {
+		c.cache.drop()
+	}
+}
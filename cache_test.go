@@ -0,0 +1,164 @@
+package supervisor
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func cacheTestServer(t *testing.T) *testServer {
+	return newTestServer(t, map[string]testHandler{
+		"supervisor.getAllConfigInfo": func(args []interface{}) (interface{}, error) {
+			return []interface{}{map[string]interface{}{"name": "web", "group": "web"}}, nil
+		},
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			return []interface{}{map[string]interface{}{"name": "web", "group": "web", "statename": "RUNNING", "state": 20}}, nil
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			return true, nil
+		},
+		"supervisor.reloadConfig": func(args []interface{}) (interface{}, error) {
+			return []interface{}{[]interface{}{[]interface{}{}, []interface{}{}, []interface{}{}}}, nil
+		},
+	})
+}
+
+func countCalls(ts *testServer, method string) int {
+	n := 0
+	for _, call := range ts.Calls() {
+		if call == method {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCacheHitAndExpiry(t *testing.T) {
+	ts := cacheTestServer(t)
+	cli, err := New(ts.URL, nil, WithCache(CacheConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	now := time.Unix(1700000000, 0)
+	cli.cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		configs, err := cli.GetAllConfigInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(configs) != 1 || configs[0].Name != "web" {
+			t.Fatalf("configs: %+v", configs)
+		}
+	}
+	if n := countCalls(ts, "supervisor.getAllConfigInfo"); n != 1 {
+		t.Fatalf("%d fetches, want 1", n)
+	}
+
+	infos, err := cli.GetAllProcessInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos[0].Name = "changed"
+	if infos, _ = cli.GetAllProcessInfo(); infos[0].Name != "web" {
+		t.Fatal("the cached result was modified through a returned slice")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cli.GetAllConfigInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countCalls(ts, "supervisor.getAllConfigInfo"); n != 2 {
+		t.Fatalf("%d fetches after expiry, want 2", n)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ts := cacheTestServer(t)
+	cli, err := New(ts.URL, nil, WithCache(CacheConfig{ProcessTTL: time.Minute}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	cli.GetAllConfigInfo()
+	cli.GetAllProcessInfo()
+	if err := cli.StartProcess("web", true); err != nil {
+		t.Fatal(err)
+	}
+	cli.GetAllConfigInfo()
+	cli.GetAllProcessInfo()
+	if n := countCalls(ts, "supervisor.getAllProcessInfo"); n != 2 {
+		t.Fatalf("%d process info fetches, want 2 after StartProcess", n)
+	}
+	if n := countCalls(ts, "supervisor.getAllConfigInfo"); n != 1 {
+		t.Fatalf("%d config fetches, want 1 after StartProcess", n)
+	}
+
+	if _, _, _, err := cli.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	cli.GetAllConfigInfo()
+	if n := countCalls(ts, "supervisor.getAllConfigInfo"); n != 2 {
+		t.Fatalf("%d config fetches, want 2 after ReloadConfig", n)
+	}
+
+	cli.InvalidateCache()
+	cli.GetAllProcessInfo()
+	if n := countCalls(ts, "supervisor.getAllProcessInfo"); n != 3 {
+		t.Fatalf("%d process info fetches, want 3 after InvalidateCache", n)
+	}
+}
+
+func TestCacheSingleFlight(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllConfigInfo": func(args []interface{}) (interface{}, error) {
+			once.Do(func() { close(entered) })
+			<-release
+			return []interface{}{map[string]interface{}{"name": "web", "group": "web"}}, nil
+		},
+	})
+	cli, err := New(ts.URL, nil, WithCache(CacheConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var wg sync.WaitGroup
+	fetch := func() {
+		defer wg.Done()
+		if configs, err := cli.GetAllConfigInfo(); err != nil || len(configs) != 1 {
+			t.Errorf("configs %+v, error %v", configs, err)
+		}
+	}
+	wg.Add(1)
+	go fetch()
+	<-entered
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go fetch()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := countCalls(ts, "supervisor.getAllConfigInfo"); n != 1 {
+		t.Fatalf("%d fetches, want 1", n)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	ts := cacheTestServer(t)
+	cli, err := New(ts.URL, nil, WithCache(CacheConfig{ConfigTTL: -1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	cli.GetAllConfigInfo()
+	cli.GetAllConfigInfo()
+	if n := countCalls(ts, "supervisor.getAllConfigInfo"); n != 2 {
+		t.Fatalf("%d fetches, want 2 with caching disabled", n)
+	}
+}
@@ -0,0 +1,283 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CanaryConfig Tune a canary rollout, zero values use the defaults
+type CanaryConfig struct {
+	Group        string           // the group with the changed config
+	Canaries     []string         // process names rolled out first, default the first member by name
+	Soak         time.Duration    // how long the canaries must stay healthy, default 5 minutes
+	PollInterval time.Duration    // default 5 seconds
+	Probes       map[string]Probe // optional by canary group:name, must pass on every poll of the soak
+	StderrBytes  int              // bytes of stderr log fetched on failure, default 2048
+	StderrLines  int              // last stderr lines reported on failure, default 20
+	// Restart Roll one member (group:name) to the new config. The default stops
+	// and starts the member waiting for RUNNING. supervisord keeps spawning a
+	// member from the config its group was added with, so that only rolls out
+	// what the command picks up itself, e.g. a new binary; set Recreate to roll
+	// out the reloaded config.
+	Restart  func(ctx context.Context, name string) error
+	Recreate *CanaryRecreate // used when Restart is nil
+}
+
+// CanaryRecreate Re-create every rolled member from the reloaded config with
+// the supervisor_twiddler plugin. getAllConfigInfo does not report options like
+// user, environment, directory or autorestart, Options must fill them in or a
+// re-created member silently runs with the supervisord defaults.
+type CanaryRecreate struct {
+	Twiddler *Twiddler // of the server the canary runs against
+	// Options Complete the options of config, pre-filled with what getAllConfigInfo
+	// reports. An error stops the rollout before the member is touched.
+	Options func(config ProgramConfig, options *TwiddlerProgramOptions) error
+}
+
+// CanaryResult The outcome of a canary rollout
+type CanaryResult struct {
+	Group         string
+	Canaries      []string // group:name
+	Promoted      []string // members rolled out after the soak
+	Err           error    // why the rollout stopped, nil when every member was rolled out
+	FailedProcess string
+	StderrTail    []string // last stderr lines of FailedProcess
+}
+
+// Canary Roll a changed group config out to a few members, soak them and then
+// promote it to the rest of the group
+type Canary struct {
+	client  Interface
+	config  CanaryConfig
+	configs map[string]ProgramConfig // reloaded configs by group:name for Recreate
+	now     func() time.Time
+}
+
+// NewCanary Create a canary rollout of config.Group
+func NewCanary(client Interface, config CanaryConfig) *Canary {
+	if config.Soak <= 0 {
+		config.Soak = 5 * time.Minute
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.StderrBytes <= 0 {
+		config.StderrBytes = 2048
+	}
+	if config.StderrLines <= 0 {
+		config.StderrLines = 20
+	}
+	c := &Canary{client: client, config: config, now: time.Now}
+	switch {
+	case c.config.Restart != nil:
+	case c.config.Recreate != nil:
+		c.configs = make(map[string]ProgramConfig)
+		c.config.Restart = c.recreate
+	default:
+		c.config.Restart = c.restart
+	}
+	return c
+}
+
+// Run Reload the config and roll out the group. A failing canary or member stops
+// the rollout and is reported in the result, the error is for everything else,
+// e.g. the group has no changed config.
+func (c *Canary) Run(ctx context.Context) (*CanaryResult, error) {
+	_, changed, _, err := c.client.ReloadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(changed, c.config.Group) {
+		return nil, fmt.Errorf("group %s has no changed config", c.config.Group)
+	}
+	if c.configs != nil {
+		if err := c.loadConfigs(); err != nil {
+			return nil, err
+		}
+	}
+	canaries, rest, err := c.members()
+	if err != nil {
+		return nil, err
+	}
+	result := &CanaryResult{Group: c.config.Group, Canaries: canaries}
+	for _, name := range canaries {
+		if err := c.config.Restart(ctx, name); err != nil {
+			return c.fail(result, name, fmt.Errorf("restart canary: %w", err))
+		}
+	}
+	if name, err := c.soak(ctx, canaries); err != nil {
+		if name == "" {
+			return nil, err
+		}
+		return c.fail(result, name, err)
+	}
+	for _, name := range rest {
+		if err := c.config.Restart(ctx, name); err != nil {
+			return c.fail(result, name, fmt.Errorf("promote: %w", err))
+		}
+		result.Promoted = append(result.Promoted, name)
+	}
+	return result, nil
+}
+
+// members Split the group into canaries and the rest, both as group:name
+func (c *Canary) members() ([]string, []string, error) {
+	infos, err := c.client.GetAllProcessInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0)
+	for _, info := range infos {
+		if info.Group == c.config.Group {
+			names = append(names, info.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("group %s has no processes", c.config.Group)
+	}
+	sort.Strings(names)
+	wanted := c.config.Canaries
+	if len(wanted) == 0 {
+		wanted = names[:1]
+	}
+	for _, name := range wanted {
+		if !containsString(names, name) {
+			return nil, nil, fmt.Errorf("canary %s is not in group %s", name, c.config.Group)
+		}
+	}
+	canaries, rest := make([]string, 0), make([]string, 0)
+	for _, name := range names {
+		if containsString(wanted, name) {
+			canaries = append(canaries, c.config.Group+":"+name)
+		} else {
+			rest = append(rest, c.config.Group+":"+name)
+		}
+	}
+	return canaries, rest, nil
+}
+
+// soak Poll the canaries until the soak ends, returning the first unhealthy
+// one. An error without a name is not the canary's fault.
+func (c *Canary) soak(ctx context.Context, canaries []string) (string, error) {
+	baseline := make(map[string]ProcessInfo, len(canaries))
+	for _, name := range canaries {
+		info, err := c.client.GetProcessInfo(name)
+		if err != nil {
+			return "", err
+		}
+		baseline[name] = info
+	}
+	deadline := c.now().Add(c.config.Soak)
+	for {
+		for _, name := range canaries {
+			info, err := c.client.GetProcessInfo(name)
+			if err != nil {
+				return "", err
+			}
+			before := baseline[name]
+			switch {
+			case info.State != ProcessRunning:
+				return name, fmt.Errorf("canary left RUNNING: %s (exit status %d)", info.StateName, info.ExitStatus)
+			case info.Start != before.Start || info.Pid != before.Pid:
+				return name, fmt.Errorf("canary restarted during the soak")
+			}
+		}
+		for _, name := range canaries {
+			if probe, ok := c.config.Probes[name]; ok {
+				if err := probe.Check(ctx); err != nil {
+					return name, fmt.Errorf("canary probe failed: %w", err)
+				}
+			}
+		}
+		if !c.now().Before(deadline) {
+			return "", nil
+		}
+		if err := sleepContext(ctx, c.config.PollInterval); err != nil {
+			return "", err
+		}
+	}
+}
+
+// fail Record why the rollout stopped together with the stderr tail of name
+func (c *Canary) fail(result *CanaryResult, name string, err error) (*CanaryResult, error) {
+	result.Err, result.FailedProcess = err, name
+	tail, tailErr := c.client.TailProcessStderrLog(name, 0, c.config.StderrBytes)
+	if tailErr == nil {
+		content := tail.Content
+		if i := strings.IndexByte(content, '\n'); tail.Overflow && i >= 0 {
+			content = content[i+1:]
+		}
+		result.StderrTail = lastLines(content, c.config.StderrLines)
+	}
+	return result, nil
+}
+
+// loadConfigs Fetch the reloaded configs of the group for Recreate
+func (c *Canary) loadConfigs() error {
+	recreate := c.config.Recreate
+	if recreate.Twiddler == nil || recreate.Options == nil {
+		return fmt.Errorf("canary Recreate needs Twiddler and Options, user, environment, directory and autorestart cannot be carried over otherwise")
+	}
+	installed, err := recreate.Twiddler.Installed()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return fmt.Errorf("canary Recreate needs the supervisor_twiddler plugin")
+	}
+	configs, err := c.client.GetAllConfigInfo()
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if config.Group == c.config.Group {
+			c.configs[config.Group+":"+config.Name] = config
+		}
+	}
+	return nil
+}
+
+// restart The default Restart: stop the member and start it waiting for RUNNING
+func (c *Canary) restart(ctx context.Context, name string) error {
+	if err := c.client.StopProcess(name, true); err != nil && !errors.Is(err, ErrNotRunning) {
+		return err
+	}
+	return c.client.StartProcess(name, true)
+}
+
+// recreate The Restart of Recreate: stop the member, re-create it from its
+// reloaded config with twiddler and start it waiting for RUNNING
+func (c *Canary) recreate(ctx context.Context, name string) error {
+	config, ok := c.configs[name]
+	if !ok {
+		return fmt.Errorf("no reloaded config for %s", name)
+	}
+	options := config.TwiddlerOptions()
+	if err := c.config.Recreate.Options(config, &options); err != nil {
+		return err
+	}
+	if err := c.client.StopProcess(name, true); err != nil && !errors.Is(err, ErrNotRunning) {
+		return err
+	}
+	twiddler := c.config.Recreate.Twiddler
+	if err := twiddler.RemoveProcessFromGroup(config.Group, config.Name); err != nil {
+		return err
+	}
+	if err := twiddler.AddProgramToGroup(config.Group, config.Name, options); err != nil {
+		return err
+	}
+	return c.client.StartProcess(name, true)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
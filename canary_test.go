@@ -0,0 +1,218 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newCanaryServer simulates group web with members web_0..web_2 and the twiddler
+// plugin. A started member whose name is in crashes exits right away, started
+// reports each start with the command the member was re-created with.
+func newCanaryServer(t *testing.T, crashes map[string]bool) (*testServer, func() []string) {
+	var mu sync.Mutex
+	infos := map[string]ProcessInfo{}
+	for i, name := range []string{"web_0", "web_1", "web_2"} {
+		infos[name] = ProcessInfo{Name: name, Group: "web", State: ProcessRunning, StateName: "RUNNING", Pid: 100 + i, Start: 1}
+	}
+	started := make([]string, 0)
+	commands := map[string]string{}
+	member := func(args []interface{}) string {
+		return strings.TrimPrefix(args[0].(string), "web:")
+	}
+	ts := newTestServer(t, map[string]testHandler{
+		"system.listMethods": func(args []interface{}) (interface{}, error) {
+			return []string{"supervisor.getAllConfigInfo", "twiddler.addProgramToGroup", "twiddler.removeProcessFromGroup"}, nil
+		},
+		"supervisor.getAPIVersion": func(args []interface{}) (interface{}, error) {
+			return "3.0", nil
+		},
+		"supervisor.getSupervisorVersion": func(args []interface{}) (interface{}, error) {
+			return "4.2.5", nil
+		},
+		"supervisor.getAllConfigInfo": func(args []interface{}) (interface{}, error) {
+			configs := make([]interface{}, 0)
+			for _, name := range []string{"web_0", "web_1", "web_2"} {
+				configs = append(configs, map[string]interface{}{"name": name, "group": "web", "command": "/srv/web --v2", "stopsignal": 15})
+			}
+			return configs, nil
+		},
+		"twiddler.removeProcessFromGroup": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			commands[args[1].(string)] = ""
+			return true, nil
+		},
+		"twiddler.addProgramToGroup": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			options := args[2].(map[string]interface{})
+			if options["autostart"] != "false" {
+				return nil, fmt.Errorf("re-created member must not autostart")
+			}
+			if options["user"] != "www" {
+				return nil, fmt.Errorf("re-created member lost its user")
+			}
+			commands[args[1].(string)] = options["command"].(string)
+			return true, nil
+		},
+		"supervisor.reloadConfig": func(args []interface{}) (interface{}, error) {
+			return [][][]string{{{}, {"web"}, {}}}, nil
+		},
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			list := make([]ProcessInfo, 0)
+			for _, info := range infos {
+				list = append(list, info)
+			}
+			return list, nil
+		},
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			info := infos[member(args)]
+			if crashes[info.Name] && info.Start == 2 {
+				info.State, info.StateName, info.ExitStatus = ProcessExited, "EXITED", 1
+			}
+			return info, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			return true, nil
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			info := infos[member(args)]
+			info.Start, info.Pid = 2, info.Pid+10
+			infos[info.Name] = info
+			started = append(started, info.Name+" "+commands[info.Name])
+			return true, nil
+		},
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"cut line\npanic: bad flag\n", 200, true}, nil
+		},
+	})
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), started...)
+	}
+}
+
+func TestCanaryPromotes(t *testing.T) {
+	ts, started := newCanaryServer(t, nil)
+	cli := ts.client(t)
+	recreate := &CanaryRecreate{
+		Twiddler: cli.Twiddler(),
+		Options: func(config ProgramConfig, options *TwiddlerProgramOptions) error {
+			options.User = "www"
+			return nil
+		},
+	}
+	canary := NewCanary(cli, CanaryConfig{Group: "web", Canaries: []string{"web_1"}, Soak: 20 * time.Millisecond, PollInterval: 5 * time.Millisecond, Recreate: recreate})
+	result, err := canary.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Err != nil || len(result.Canaries) != 1 || result.Canaries[0] != "web:web_1" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if strings.Join(result.Promoted, ",") != "web:web_0,web:web_2" || strings.Join(started(), ",") != "web_1 /srv/web --v2,web_0 /srv/web --v2,web_2 /srv/web --v2" {
+		t.Fatalf("expected the canary first and then the rest but %v %v", result.Promoted, started())
+	}
+}
+
+func TestCanaryFails(t *testing.T) {
+	ts, started := newCanaryServer(t, map[string]bool{"web_0": true})
+	canary := NewCanary(ts.client(t), CanaryConfig{Group: "web", Soak: time.Second, PollInterval: 5 * time.Millisecond})
+	result, err := canary.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Err == nil || result.FailedProcess != "web:web_0" || len(result.Promoted) != 0 {
+		t.Fatalf("expected the canary to fail but %+v", result)
+	}
+	if len(result.StderrTail) != 1 || result.StderrTail[0] != "panic: bad flag" {
+		t.Fatalf("unexpected stderr tail %q", result.StderrTail)
+	}
+	if got := started(); len(got) != 1 {
+		t.Fatalf("nothing must be promoted after a failed canary: %v", got)
+	}
+	if _, err := NewCanary(ts.client(t), CanaryConfig{Group: "jobs"}).Run(context.Background()); err == nil {
+		t.Fatal("expected an error for a group without changes")
+	}
+}
+
+func TestCanaryRestartsByDefault(t *testing.T) {
+	ts, started := newCanaryServer(t, nil)
+	canary := NewCanary(ts.client(t), CanaryConfig{Group: "web", Soak: time.Millisecond, PollInterval: time.Millisecond})
+	result, err := canary.Run(context.Background())
+	if err != nil || result.Err != nil || strings.Join(started(), ",") != "web_0 ,web_1 ,web_2 " {
+		t.Fatalf("expected a plain restart of every member but %+v %v %v", result, err, started())
+	}
+	for _, call := range ts.Calls() {
+		if strings.HasPrefix(call, "twiddler.") {
+			t.Fatalf("the default restart must not use twiddler: %v", ts.Calls())
+		}
+	}
+}
+
+func TestCanaryProbeBlamesProbedMember(t *testing.T) {
+	ts, _ := newCanaryServer(t, nil)
+	canary := NewCanary(ts.client(t), CanaryConfig{
+		Group:        "web",
+		Canaries:     []string{"web_0", "web_2"},
+		Soak:         time.Second,
+		PollInterval: time.Millisecond,
+		Probes: map[string]Probe{
+			"web:web_0": &scriptedProbe{results: []error{nil}},
+			"web:web_2": &scriptedProbe{results: []error{fmt.Errorf("connection refused")}},
+		},
+	})
+	result, err := canary.Run(context.Background())
+	if err != nil || result.Err == nil || result.FailedProcess != "web:web_2" {
+		t.Fatalf("expected web:web_2 blamed for its probe but %+v %v", result, err)
+	}
+}
+
+func TestCanaryRecreateNeedsOptions(t *testing.T) {
+	ts, started := newCanaryServer(t, nil)
+	cli := ts.client(t)
+	if _, err := NewCanary(cli, CanaryConfig{Group: "web", Recreate: &CanaryRecreate{Twiddler: cli.Twiddler()}}).Run(context.Background()); err == nil {
+		t.Fatal("expected an error for Recreate without Options")
+	}
+	ts.handle("system.listMethods", func(args []interface{}) (interface{}, error) {
+		return []string{"supervisor.getState"}, nil
+	})
+	cli = ts.client(t)
+	recreate := &CanaryRecreate{
+		Twiddler: cli.Twiddler(),
+		Options: func(config ProgramConfig, options *TwiddlerProgramOptions) error {
+			return nil
+		},
+	}
+	if _, err := NewCanary(cli, CanaryConfig{Group: "web", Recreate: recreate}).Run(context.Background()); err == nil {
+		t.Fatal("expected an error for Recreate without twiddler")
+	}
+	if got := started(); len(got) != 0 {
+		t.Fatalf("nothing must be restarted: %v", got)
+	}
+}
+
+func TestCanaryCustomRestart(t *testing.T) {
+	ts, started := newCanaryServer(t, nil)
+	restarted := make([]string, 0)
+	canary := NewCanary(ts.client(t), CanaryConfig{Group: "web", Soak: time.Millisecond, PollInterval: time.Millisecond,
+		Restart: func(ctx context.Context, name string) error {
+			restarted = append(restarted, name)
+			return nil
+		}})
+	result, err := canary.Run(context.Background())
+	if err != nil || result.Err != nil || len(restarted) != 3 || len(started()) != 0 {
+		t.Fatalf("expected the custom Restart for every member but %+v %v %v", result, err, restarted)
+	}
+}
@@ -0,0 +1,173 @@
+package supervisor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// optionalMethods Methods missing from some supervisord 3.x releases, they are
+// checked against system.listMethods before being sent
+var optionalMethods = map[string]bool{
+	"supervisor.signalProcess":       true,
+	"supervisor.signalProcessGroup":  true,
+	"supervisor.signalAllProcesses":  true,
+	"supervisor.getAllConfigInfo":    true,
+	"supervisor.sendRemoteCommEvent": true,
+}
+
+// capabilitiesRetry How long a failed discovery is not retried for checks before calls
+const capabilitiesRetry = 30 * time.Second
+
+// Capabilities What the connected supervisord provides
+type Capabilities struct {
+	APIVersion        string
+	SupervisorVersion string
+	Methods           []string // sorted
+	methods           map[string]bool
+}
+
+// NewCapabilities Create the capabilities of a server providing methods
+func NewCapabilities(apiVersion, supervisorVersion string, methods []string) *Capabilities {
+	methods = append([]string(nil), methods...)
+	sort.Strings(methods)
+	caps := &Capabilities{
+		APIVersion:        apiVersion,
+		SupervisorVersion: supervisorVersion,
+		Methods:           methods,
+		methods:           make(map[string]bool, len(methods)),
+	}
+	for _, method := range methods {
+		caps.methods[method] = true
+	}
+	return caps
+}
+
+// Has Report whether the server provides method, e.g. "supervisor.signalProcess"
+func (c *Capabilities) Has(method string) bool {
+	return c.methods[method]
+}
+
+// HasNamespace Report whether any method of the namespace is provided, e.g. an rpcinterface plugin
+func (c *Capabilities) HasNamespace(ns Namespace) bool {
+	prefix := string(ns) + "."
+	for _, method := range c.Methods {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupervisorAtLeast Report whether the supervisor package is at least version, e.g. "3.2.0"
+func (c *Capabilities) SupervisorAtLeast(version string) bool {
+	return compareVersions(c.SupervisorVersion, version) >= 0
+}
+
+// APIAtLeast Report whether the RPC API is at least version, e.g. "3.0"
+func (c *Capabilities) APIAtLeast(version string) bool {
+	return compareVersions(c.APIVersion, version) >= 0
+}
+
+// Capabilities Return the capabilities of the server, they are discovered on
+// first use with ListMethods, GetAPIVersion and GetSupervisorVersion and cached
+func (c *Client) Capabilities() (*Capabilities, error) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.caps != nil {
+		return c.caps, nil
+	}
+	methods, err := c.ListMethods()
+	if err != nil {
+		c.capsFailed = time.Now()
+		return nil, err
+	}
+	apiVersion, err := c.GetAPIVersion()
+	if err != nil {
+		c.capsFailed = time.Now()
+		return nil, err
+	}
+	supervisorVersion, err := c.GetSupervisorVersion()
+	if err != nil {
+		c.capsFailed = time.Now()
+		return nil, err
+	}
+	c.caps = NewCapabilities(apiVersion, supervisorVersion, methods)
+	return c.caps, nil
+}
+
+// RefreshCapabilities Forget the cached capabilities, e.g. after upgrading supervisord
+func (c *Client) RefreshCapabilities() {
+	c.capsMu.Lock()
+	c.caps, c.capsFailed = nil, time.Time{}
+	c.capsMu.Unlock()
+	if c.cache != nil {
+		c.cache.drop("system.listMethods", "supervisor.getAPIVersion", "supervisor.getSupervisorVersion")
+	}
+}
+
+// Supports Report whether the server provides method, e.g. "supervisor.signalProcess"
+func (c *Client) Supports(method string) (bool, error) {
+	caps, err := c.Capabilities()
+	if err != nil {
+		return false, err
+	}
+	return caps.Has(method), nil
+}
+
+// checkSupported Return an *UnsupportedError for optional and plugin methods the
+// server does not list. When discovery fails the call is sent anyway, and an
+// UNKNOWN_METHOD fault still matches ErrUnsupported; discovery is not retried
+// for capabilitiesRetry.
+func (c *Client) checkSupported(ns Namespace, method string) error {
+	if ns == SystemNamespace || (ns == DefaultNamespace && !optionalMethods[method]) {
+		return nil
+	}
+	c.capsMu.Lock()
+	failed := c.caps == nil && time.Since(c.capsFailed) < capabilitiesRetry
+	c.capsMu.Unlock()
+	if failed {
+		return nil
+	}
+	caps, err := c.Capabilities()
+	if err != nil {
+		return nil
+	}
+	if !caps.Has(method) {
+		return &UnsupportedError{Method: method}
+	}
+	return nil
+}
+
+// compareVersions Compare dotted versions numerically, e.g. "3.10" > "3.9"
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = leadingNumber(as[i])
+		}
+		if i < len(bs) {
+			y = leadingNumber(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// leadingNumber Parse the digits at the start of s, e.g. "0b1" is 0
+func leadingNumber(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
@@ -0,0 +1,103 @@
+package supervisor
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"system.listMethods": func(args []interface{}) (interface{}, error) {
+			return []string{"system.listMethods", "supervisor.getAPIVersion", "supervisor.stopProcess"}, nil
+		},
+		"supervisor.getAPIVersion": func(args []interface{}) (interface{}, error) {
+			return "3.0", nil
+		},
+		"supervisor.getSupervisorVersion": func(args []interface{}) (interface{}, error) {
+			return "3.1.4", nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			return nil, testFault{StatusNotRunning, "NOT_RUNNING"}
+		},
+	})
+	cli := ts.client(t)
+	caps, err := cli.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.SupervisorAtLeast("3.1") || caps.SupervisorAtLeast("3.2.0") || !caps.APIAtLeast("3.0") {
+		t.Fatalf("unexpected version comparison for %s", caps.SupervisorVersion)
+	}
+	if ok, _ := cli.Supports("supervisor.signalProcess"); ok {
+		t.Fatal("signalProcess should not be supported")
+	}
+
+	calls := len(ts.Calls())
+	err = cli.SignalProcess("web", syscall.SIGHUP)
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) || !errors.Is(err, ErrUnsupported) || unsupported.Method != "supervisor.signalProcess" {
+		t.Fatalf("expected ErrUnsupported but %v", err)
+	}
+	if len(ts.Calls()) != calls {
+		t.Fatal("unsupported call was sent to the server")
+	}
+
+	err = cli.StopProcess("web", true)
+	var fault *Fault
+	if !errors.As(err, &fault) || fault.Code != StatusNotRunning || !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected NOT_RUNNING fault but %v", err)
+	}
+	if err.Error() != "Fault(70): NOT_RUNNING" {
+		t.Fatalf("unexpected fault text %q", err.Error())
+	}
+	if _, err := cli.GetPID(); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected UNKNOWN_METHOD fault to match ErrUnsupported but %v", err)
+	}
+}
+
+func TestCapabilitiesDiscoveryFailure(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"system.listMethods": func(args []interface{}) (interface{}, error) {
+			return nil, testFault{StatusFailed, "FAILED: listMethods"}
+		},
+		"supervisor.signalProcess": func(args []interface{}) (interface{}, error) {
+			return true, nil
+		},
+	})
+	cli := ts.client(t)
+	for i := 0; i < 3; i++ {
+		if err := cli.SignalProcessNamed("web", SignalHUP); err != nil {
+			t.Fatal(err)
+		}
+	}
+	discoveries := 0
+	for _, call := range ts.Calls() {
+		if call == "system.listMethods" {
+			discoveries++
+		}
+	}
+	if discoveries != 1 {
+		t.Fatalf("expected one failed discovery to be remembered but %d", discoveries)
+	}
+	if _, err := cli.GetAllConfigInfo(); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected UNKNOWN_METHOD to match ErrUnsupported but %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"3.10", "3.9", 1},
+		{"4.2.2", "4.2.2", 0},
+		{"3.0b1", "3.0", 0},
+		{"3.0", "3.0.1", -1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -3,34 +3,62 @@ package supervisor
 import (
 	"fmt"
 	"net/http"
+	"net/rpc"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/kolo/xmlrpc"
 )
 
+// Client A supervisord xml rpc client. It is safe for concurrent use by
+// multiple goroutines. Each connection carries one request at a time, callers
+// wait for a free one in priority order; WithMaxInFlight opens more.
 type Client struct {
-	url    string
-	client *xmlrpc.Client
+	url     string
+	clients []*xmlrpc.Client
+	idle    chan *xmlrpc.Client
+	limiter *limiter
+	cache   *callCache // nil unless WithCache
+
+	capsMu     sync.Mutex
+	caps       *Capabilities
+	capsFailed time.Time // last failed discovery
 }
 
 // New Create new supervisor xml rpc client
-func New(url string, transport http.RoundTripper) (*Client, error) {
-	rpcClient, err := xmlrpc.NewClient(url+"/RPC2", transport)
-	if err != nil {
-		return nil, err
-	}
+func New(url string, transport http.RoundTripper, opts ...Option) (*Client, error) {
 	cli := &Client{
-		client: rpcClient,
-		url:    url,
+		url:     url,
+		limiter: newLimiter(),
+	}
+	for _, opt := range opts {
+		opt(cli)
+	}
+	cli.idle = make(chan *xmlrpc.Client, cli.limiter.max)
+	for i := 0; i < cli.limiter.max; i++ {
+		rpcClient, err := xmlrpc.NewClient(url+"/RPC2", transport)
+		if err != nil {
+			cli.Close()
+			return nil, err
+		}
+		cli.clients = append(cli.clients, rpcClient)
+		cli.idle <- rpcClient
 	}
 	return cli, nil
 }
 
 func (c *Client) Close() error {
-	if c.client != nil {
-		return c.client.Close()
+	var err error
+	for _, rpcClient := range c.clients {
+		if closeErr := rpcClient.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
 	}
-	return nil
+	return err
 }
 
 // ListMethods Return an array listing the available method names
@@ -192,35 +220,96 @@ func (c *Client) StopAllProcesses(wait bool) ([]ActionStatus, error) {
 	return infos, err
 }
 
-// SignalProcess Send an arbitrary UNIX signal to the process named by name
+// SignalProcess Send an arbitrary UNIX signal to the process named by name.
+// The signal is sent by name, ErrBadSignal for signals supervisord rejects.
 func (c *Client) SignalProcess(name string, signal syscall.Signal) error {
+	sig, err := wireSignal(Signal(signal))
+	if err != nil {
+		return err
+	}
 	var flag bool
-	args := []interface{}{name, signal}
-	err := c.call(DefaultNamespace, "signalProcess", args, &flag)
+	args := []interface{}{name, sig}
+	err = c.call(DefaultNamespace, "signalProcess", args, &flag)
 	return err
 }
 
 // SignalProcessGroup Send a signal to all processes in the group named 'name'
 func (c *Client) SignalProcessGroup(name string, signal syscall.Signal) ([]ActionStatus, error) {
+	sig, err := wireSignal(Signal(signal))
+	if err != nil {
+		return nil, err
+	}
 	infos := make([]ActionStatus, 0)
-	args := []interface{}{name, signal}
-	err := c.call(DefaultNamespace, "signalProcessGroup", args, &infos)
+	args := []interface{}{name, sig}
+	err = c.call(DefaultNamespace, "signalProcessGroup", args, &infos)
 	return infos, err
 }
 
 // SignalAllProcesses Send a signal to all processes in the process list
 func (c *Client) SignalAllProcesses(signal syscall.Signal) ([]ActionStatus, error) {
+	sig, err := wireSignal(Signal(signal))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ActionStatus, 0)
+	args := []interface{}{sig}
+	err = c.call(DefaultNamespace, "signalAllProcesses", args, &infos)
+	return infos, err
+}
+
+// SignalProcessNamed SignalProcess taking a Signal, e.g. one parsed from a
+// config, so the numbering of the client platform does not matter
+func (c *Client) SignalProcessNamed(name string, signal Signal) error {
+	sig, err := wireSignal(signal)
+	if err != nil {
+		return err
+	}
+	var flag bool
+	args := []interface{}{name, sig}
+	err = c.call(DefaultNamespace, "signalProcess", args, &flag)
+	return err
+}
+
+// SignalProcessGroupNamed SignalProcessGroup taking a Signal
+func (c *Client) SignalProcessGroupNamed(name string, signal Signal) ([]ActionStatus, error) {
+	sig, err := wireSignal(signal)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ActionStatus, 0)
+	args := []interface{}{name, sig}
+	err = c.call(DefaultNamespace, "signalProcessGroup", args, &infos)
+	return infos, err
+}
+
+// SignalAllProcessesNamed SignalAllProcesses taking a Signal
+func (c *Client) SignalAllProcessesNamed(signal Signal) ([]ActionStatus, error) {
+	sig, err := wireSignal(signal)
+	if err != nil {
+		return nil, err
+	}
 	infos := make([]ActionStatus, 0)
-	args := []interface{}{signal}
-	err := c.call(DefaultNamespace, "signalAllProcesses", args, &infos)
+	args := []interface{}{sig}
+	err = c.call(DefaultNamespace, "signalAllProcesses", args, &infos)
 	return infos, err
 }
 
 // GetAllConfigInfo Get info about all available process configurations. Each struct represents a single process (i.e. groups get flattened).
-func (c *Client) GetAllConfigInfo() ([]ProgramConfig, error) { // fixme: should return struct, not interface
-	configs := make([]ProgramConfig, 0)
-	err := c.call(DefaultNamespace, "getAllConfigInfo", nil, &configs)
-	return configs, err
+// stopsignal is decoded from a number as well as a name.
+func (c *Client) GetAllConfigInfo() ([]ProgramConfig, error) {
+	raw := make([]map[string]interface{}, 0)
+	if err := c.call(DefaultNamespace, "getAllConfigInfo", nil, &raw); err != nil {
+		return nil, err
+	}
+	configs := make([]ProgramConfig, 0, len(raw))
+	for _, m := range raw {
+		config, err := decodeProgramConfig(m)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
 }
 
 // GetProcessInfo Get info about a process named name
@@ -271,12 +360,7 @@ func (c *Client) TailProcessStdoutLog(name string, offset, length int) (*TailRes
 	if err != nil {
 		return nil, err
 	}
-	tail := &TailResult{
-		Content:  result[0].(string),
-		Offset:   result[1].(int64),
-		Overflow: result[2].(bool),
-	}
-	return tail, nil
+	return decodeTailResult(result)
 }
 
 // TailProcessStderrLog Provides a more efficient way to tail the (stderr) log than ReadProcessStderrLog().  Use ReadProcessStderrLog() to read chunks and TailProcessStderrLog() to tail.
@@ -296,12 +380,7 @@ func (c *Client) TailProcessStderrLog(name string, offset, length int) (*TailRes
 	if err != nil {
 		return nil, err
 	}
-	tail := &TailResult{
-		Content:  result[0].(string),
-		Offset:   result[1].(int64),
-		Overflow: result[2].(bool),
-	}
-	return tail, nil
+	return decodeTailResult(result)
 }
 
 // ClearProcessLogs Clear the stdout and stderr logs for the named process and reopen them.
@@ -333,6 +412,131 @@ func (c *Client) SendProcessStdin(name, chars string) error {
 	return err
 }
 
+// decodeTailResult Decode [string bytes, int offset, bool overflow], an empty string is decoded as nil
+func decodeTailResult(result []interface{}) (*TailResult, error) {
+	if len(result) != 3 {
+		return nil, fmt.Errorf("invalid tail result %v", result)
+	}
+	content, _ := result[0].(string)
+	offset, ok := result[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("invalid tail offset %v", result[1])
+	}
+	overflow, _ := result[2].(bool)
+	tail := &TailResult{
+		Content:  content,
+		Offset:   offset,
+		Overflow: overflow,
+	}
+	return tail, nil
+}
+
+// decodeProgramConfig Decode one getAllConfigInfo struct by the xmlrpc tags,
+// keys without a field and empty strings (decoded as nil) are skipped
+func decodeProgramConfig(m map[string]interface{}) (ProgramConfig, error) {
+	config := ProgramConfig{}
+	v := reflect.ValueOf(&config).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("xmlrpc")
+		value, ok := m[key]
+		if !ok || value == nil {
+			continue
+		}
+		if err := setConfigField(v.Field(i), value); err != nil {
+			return config, fmt.Errorf("decode %s of %v: %w", key, m["name"], err)
+		}
+	}
+	return config, nil
+}
+
+func setConfigField(field reflect.Value, value interface{}) error {
+	if field.Type() == reflect.TypeOf(Signal(0)) {
+		signal, err := decodeSignal(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(signal))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			field.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			field.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int64:
+		if n, ok := value.(int64); ok {
+			field.SetInt(n)
+			return nil
+		}
+	case reflect.Slice:
+		if items, ok := value.([]interface{}); ok && field.Type().Elem().Kind() == reflect.Int {
+			ints := make([]int, 0, len(items))
+			for _, item := range items {
+				n, ok := item.(int64)
+				if !ok {
+					return fmt.Errorf("unexpected %T in list", item)
+				}
+				ints = append(ints, int(n))
+			}
+			field.Set(reflect.ValueOf(ints))
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected %T", value)
+}
+
 func (c *Client) call(ns Namespace, method string, args interface{}, relay interface{}) error {
-	return c.client.Call(fmt.Sprintf("%s.%s", ns, method), args, relay)
+	name := fmt.Sprintf("%s.%s", ns, method)
+	if err := c.checkSupported(ns, name); err != nil {
+		return err
+	}
+	if c.cache == nil {
+		return c.send(name, args, relay)
+	}
+	if c.cache.cacheable(name) {
+		return c.cache.get(name, relay, func(relay interface{}) error {
+			return c.send(name, args, relay)
+		})
+	}
+	defer c.cache.invalidate(name)
+	return c.send(name, args, relay)
+}
+
+// send Send one request on an idle connection, waiting for one in priority order.
+// net/rpc serializes the calls of one xmlrpc.Client, so the limiter must be the
+// only place callers wait.
+func (c *Client) send(name string, args interface{}, relay interface{}) error {
+	c.limiter.acquire(name)
+	rpcClient := <-c.idle
+	defer func() {
+		c.idle <- rpcClient
+		c.limiter.release()
+	}()
+	return parseFault(rpcClient.Call(name, args, relay))
+}
+
+var faultPattern = regexp.MustCompile(`^Fault\((-?\d+)\): ((?s).*)$`)
+
+// parseFault Turn the fault text reported by net/rpc back into a *Fault
+func parseFault(err error) error {
+	serverErr, ok := err.(rpc.ServerError)
+	if !ok {
+		return err
+	}
+	m := faultPattern.FindStringSubmatch(string(serverErr))
+	if m == nil {
+		return err
+	}
+	code, convErr := strconv.Atoi(m[1])
+	if convErr != nil {
+		return err
+	}
+	return &Fault{Code: Status(code), String: m[2]}
 }
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	sc "github.com/lixianyang/supervisor-client"
+)
+
+// config What to generate
+type config struct {
+	Namespace string // e.g. "twiddler"
+	Package   string // package of the generated file
+	Type      string // generated receiver type, e.g. "Twiddler"
+	Source    string // where the introspection came from, for the header
+}
+
+// xmlrpcTypes Go types of the xml rpc type names used in signatures and @param tags
+var xmlrpcTypes = map[string]string{
+	"string":           "string",
+	"base64":           "string",
+	"int":              "int",
+	"i4":               "int",
+	"integer":          "int",
+	"boolean":          "bool",
+	"bool":             "bool",
+	"double":           "float64",
+	"float":            "float64",
+	"array":            "[]interface{}",
+	"list":             "[]interface{}",
+	"struct":           "map[string]interface{}",
+	"dict":             "map[string]interface{}",
+	"dateTime.iso8601": "time.Time",
+}
+
+type param struct {
+	Name string
+	Type string
+	Doc  string
+}
+
+type method struct {
+	Name    string // name without namespace
+	GoName  string
+	Doc     []string
+	Params  []param
+	Returns string // Go type, "" when only an error is returned
+}
+
+// generate Render Go wrappers for the methods of cfg.Namespace
+func generate(cfg config, introspection *sc.Introspection) ([]byte, error) {
+	methods := make([]method, 0)
+	for _, desc := range introspection.Methods {
+		ns, name, err := sc.SplitMethod(desc.Name)
+		if err != nil || string(ns) != cfg.Namespace {
+			continue
+		}
+		methods = append(methods, describe(name, desc))
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no methods in namespace %q", cfg.Namespace)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].GoName < methods[j].GoName
+	})
+
+	qualifier := "sc."
+	if cfg.Package == "supervisor" {
+		qualifier = ""
+	}
+	usesTime := false
+	for _, m := range methods {
+		usesTime = usesTime || m.Returns == "time.Time"
+		for _, p := range m.Params {
+			usesTime = usesTime || p.Type == "time.Time"
+		}
+	}
+	receiver := strings.ToLower(cfg.Type[:1])
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "// Code generated by supervisor-gen from %s; DO NOT EDIT.\n\n", cfg.Source)
+	fmt.Fprintf(&b, "package %s\n\n", cfg.Package)
+	if qualifier != "" || usesTime {
+		b.WriteString("import (\n")
+		if usesTime {
+			b.WriteString("\"time\"\n")
+		}
+		if qualifier != "" {
+			b.WriteString("sc \"github.com/lixianyang/supervisor-client\"\n")
+		}
+		b.WriteString(")\n\n")
+	}
+	fmt.Fprintf(&b, "// %s Typed access to the %s rpcinterface\n", cfg.Type, cfg.Namespace)
+	fmt.Fprintf(&b, "type %s struct {\n\tclient *%sClient\n}\n\n", cfg.Type, qualifier)
+	fmt.Fprintf(&b, "// New%s Create %s methods on top of client\n", cfg.Type, cfg.Namespace)
+	fmt.Fprintf(&b, "func New%s(client *%sClient) *%s {\n\treturn &%s{client: client}\n}\n", cfg.Type, qualifier, cfg.Type, cfg.Type)
+
+	for _, m := range methods {
+		b.WriteString("\n")
+		for i, line := range m.Doc {
+			if i == 0 {
+				line = m.GoName + " " + line
+			}
+			b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+		}
+		params := make([]string, 0, len(m.Params))
+		args := make([]string, 0, len(m.Params))
+		for _, p := range paramNames(m.Params, receiver) {
+			params = append(params, p.Name+" "+p.Type)
+			args = append(args, p.Name)
+		}
+		results := "error"
+		if m.Returns != "" {
+			results = "(" + m.Returns + ", error)"
+		}
+		fmt.Fprintf(&b, "func (%s *%s) %s(%s) %s {\n", receiver, cfg.Type, m.GoName, strings.Join(params, ", "), results)
+		if m.Returns != "" {
+			fmt.Fprintf(&b, "var result %s\n", m.Returns)
+		} else {
+			b.WriteString("var flag bool\n")
+		}
+		if len(args) > 0 {
+			fmt.Fprintf(&b, "args := []interface{}{%s}\n", strings.Join(args, ", "))
+		} else {
+			b.WriteString("var args []interface{}\n")
+		}
+		if m.Returns != "" {
+			fmt.Fprintf(&b, "err := %s.client.Call(%q, %q, args, &result)\nreturn result, err\n}\n", receiver, cfg.Namespace, m.Name)
+		} else {
+			fmt.Fprintf(&b, "err := %s.client.Call(%q, %q, args, &flag)\nreturn err\n}\n", receiver, cfg.Namespace, m.Name)
+		}
+	}
+	return format.Source([]byte(b.String()))
+}
+
+// paramNames Rename params colliding with the locals and imports of a generated
+// method, or with each other, by adding an Arg suffix
+func paramNames(params []param, receiver string) []param {
+	taken := map[string]bool{receiver: true, "args": true, "result": true, "flag": true, "err": true, "sc": true, "time": true}
+	renamed := make([]param, 0, len(params))
+	for _, p := range params {
+		for taken[p.Name] {
+			p.Name += "Arg"
+		}
+		taken[p.Name] = true
+		renamed = append(renamed, p)
+	}
+	return renamed
+}
+
+// describe Build a method from the docstring tags and the signature
+func describe(name string, desc sc.MethodDescription) method {
+	m := method{Name: name, GoName: exported(name)}
+	returnType := ""
+	for _, line := range strings.Split(desc.Help, "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 3 && fields[0] == "@param":
+			p := param{Type: goType(fields[1]), Name: identifier(fields[2])}
+			p.Doc = strings.Join(append([]string{fields[1], fields[2]}, fields[3:]...), " ")
+			m.Params = append(m.Params, p)
+		case len(fields) >= 2 && fields[0] == "@return":
+			returnType = fields[1]
+		case strings.HasPrefix(line, "@"):
+		case line != "" && len(m.Params) == 0 && returnType == "":
+			m.Doc = append(m.Doc, line)
+		}
+	}
+	if len(desc.Signatures) > 0 {
+		signature := desc.Signatures[0]
+		returnType = signature.Return
+		for i, typ := range signature.Params {
+			if i < len(m.Params) {
+				m.Params[i].Type = goType(typ)
+			} else {
+				m.Params = append(m.Params, param{Name: fmt.Sprintf("arg%d", i), Type: goType(typ)})
+			}
+		}
+	}
+	if returnType != "" && returnType != "boolean" && returnType != "bool" {
+		m.Returns = goType(returnType)
+	}
+	if len(m.Doc) == 0 {
+		m.Doc = []string{"Call " + desc.Name}
+	}
+	for _, p := range m.Params {
+		if p.Doc != "" {
+			m.Doc = append(m.Doc, p.Doc)
+		}
+	}
+	return m
+}
+
+func goType(xmlrpcType string) string {
+	if t, ok := xmlrpcTypes[xmlrpcType]; ok {
+		return t
+	}
+	return "interface{}"
+}
+
+// exported Turn a method name like getAPIVersion into GetAPIVersion
+func exported(name string) string {
+	r := []rune(identifier(name))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// identifier Turn a docstring name into a valid Go identifier
+func identifier(name string) string {
+	b := strings.Builder{}
+	upper := false
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			b.WriteRune(r)
+		default:
+			upper = b.Len() > 0
+		}
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "arg" + id
+	}
+	if token.IsKeyword(id) {
+		id += "_"
+	}
+	return id
+}
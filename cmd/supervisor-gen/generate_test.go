@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	sc "github.com/lixianyang/supervisor-client"
+)
+
+func TestGenerate(t *testing.T) {
+	introspection := &sc.Introspection{Methods: []sc.MethodDescription{
+		{
+			Name:       "twiddler.getGroupNames",
+			Help:       "Return an array with the names of the process groups.\n\n@return array Process group names",
+			Signatures: []sc.MethodSignature{{Return: "array"}},
+		},
+		{
+			Name: "twiddler.removeProcessFromGroup",
+			Help: "Remove a process from a process group.\n\n@param string group_name Name of an existing process group\n" +
+				"@param string process_name Name of the process\n@return boolean Always True unless error",
+			Signatures: []sc.MethodSignature{{Return: "boolean", Params: []string{"string", "string"}}},
+		},
+		{
+			Name:       "twiddler.log",
+			Help:       "Log a message.\n\n@param string result Message\n@param string t Level\n@param string err Origin\n@return boolean Always True",
+			Signatures: []sc.MethodSignature{{Return: "boolean", Params: []string{"string", "string", "string"}}},
+		},
+		{Name: "supervisor.getPID", Help: "Return the PID of supervisord"},
+	}}
+	code, err := generate(config{Namespace: "twiddler", Package: "plugins", Type: "Twiddler", Source: "dump.json"}, introspection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(code)
+	for _, want := range []string{
+		"// GetGroupNames Return an array with the names of the process groups.\nfunc (t *Twiddler) GetGroupNames() ([]interface{}, error) {",
+		"// string group_name Name of an existing process group\n",
+		"func (t *Twiddler) RemoveProcessFromGroup(groupName string, processName string) error {",
+		`err := t.client.Call("twiddler", "removeProcessFromGroup", args, &flag)`,
+		"func (t *Twiddler) Log(resultArg string, tArg string, errArg string) error {",
+		"args := []interface{}{resultArg, tArg, errArg}",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code misses %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "GetPID") {
+		t.Error("generated a method of another namespace")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	sc "github.com/lixianyang/supervisor-client"
+)
+
+// fg Attach the terminal to a running process until Ctrl-] is typed
+func fg(client *sc.Client, name string) error {
+	info, err := client.GetProcessInfo(name)
+	if err != nil {
+		return err
+	}
+	if info.State != sc.ProcessRunning {
+		return fmt.Errorf("%s is not running", name)
+	}
+	restore, err := rawMode()
+	raw := err == nil
+	if raw {
+		defer restore()
+	}
+	session, err := client.Attach(context.Background(), name, sc.AttachConfig{Echo: raw})
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	newline := "\n"
+	if raw {
+		newline = "\r\n"
+	}
+	fmt.Fprint(os.Stdout, "==> Press Ctrl-] to exit <=="+newline)
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(terminalWriter{os.Stdout, raw}, session)
+		errs <- err
+	}()
+	go func() {
+		_, err := io.Copy(session, os.Stdin)
+		if errors.Is(err, sc.ErrDetached) {
+			err = nil
+		}
+		errs <- err
+	}()
+	return <-errs
+}
+
+// terminalWriter Turn \n into \r\n for a terminal in raw mode
+type terminalWriter struct {
+	w   io.Writer
+	raw bool
+}
+
+func (t terminalWriter) Write(p []byte) (int, error) {
+	if !t.raw {
+		return t.w.Write(p)
+	}
+	if _, err := io.WriteString(t.w, strings.Replace(string(p), "\n", "\r\n", -1)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rawMode Put the terminal on stdin in raw mode with stty, an error means stdin
+// is not a terminal
+func rawMode() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(state)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,201 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CrashLoopConfig Tune crash loop classification, zero values use the defaults
+type CrashLoopConfig struct {
+	Window      time.Duration // sliding window restarts are counted in, default 5 minutes
+	MaxRestarts int           // restarts within Window classifying a crash loop, default 5
+	Quarantine  bool          // stop the offender with StopProcess once classified
+	StderrBytes int           // bytes of stderr log fetched for the report, default 4096
+	StderrLines int           // last stderr lines kept in the report, default 20
+}
+
+// CrashLoop A process classified as crash looping
+type CrashLoop struct {
+	Name          string // group:name
+	Restarts      int    // restarts within the window when classified
+	Window        time.Duration
+	State         ProcessState
+	StateName     string
+	ExitStatus    int
+	SpawnErr      string
+	StderrTail    []string
+	Quarantined   bool
+	QuarantineErr error
+	DetectedAt    time.Time
+}
+
+// CrashLoopMonitor Track restarts per process in a sliding window and classify crash loops.
+// Restarts are detected from pid and Start changes between polls.
+type CrashLoopMonitor struct {
+	client   Interface
+	config   CrashLoopConfig
+	watcher  *Watcher
+	restarts map[string][]time.Time
+	looping  map[string]CrashLoop
+	now      func() time.Time
+}
+
+// NewCrashLoopMonitor Create a crash loop monitor
+func NewCrashLoopMonitor(client Interface, config CrashLoopConfig) *CrashLoopMonitor {
+	if config.Window <= 0 {
+		config.Window = 5 * time.Minute
+	}
+	if config.MaxRestarts <= 0 {
+		config.MaxRestarts = 5
+	}
+	if config.StderrBytes <= 0 {
+		config.StderrBytes = 4096
+	}
+	if config.StderrLines <= 0 {
+		config.StderrLines = 20
+	}
+	return &CrashLoopMonitor{
+		client:   client,
+		config:   config,
+		watcher:  NewWatcher(client),
+		restarts: make(map[string][]time.Time),
+		looping:  make(map[string]CrashLoop),
+		now:      time.Now,
+	}
+}
+
+// Poll Poll process info once and return the processes newly classified as crash looping
+func (m *CrashLoopMonitor) Poll() ([]CrashLoop, error) {
+	transitions, err := m.watcher.Poll()
+	if err != nil {
+		return nil, err
+	}
+	return m.Observe(transitions), nil
+}
+
+// Observe Account transitions obtained elsewhere and return the newly classified crash loops
+func (m *CrashLoopMonitor) Observe(transitions []Transition) []CrashLoop {
+	now := m.now()
+	detected := make([]CrashLoop, 0)
+	for _, t := range transitions {
+		if t.Removed {
+			delete(m.restarts, t.Name)
+			delete(m.looping, t.Name)
+			continue
+		}
+		if !t.Restarted() {
+			continue
+		}
+		m.restarts[t.Name] = append(m.restarts[t.Name], now)
+		count := m.expire(t.Name, now)
+		if _, ok := m.looping[t.Name]; ok || count < m.config.MaxRestarts {
+			continue
+		}
+		loop := m.classify(t.To, count, now)
+		m.looping[t.Name] = loop
+		detected = append(detected, loop)
+	}
+	for name, loop := range m.looping {
+		if !loop.Quarantined && m.expire(name, now) == 0 {
+			delete(m.looping, name)
+		}
+	}
+	return detected
+}
+
+// Run Poll every interval and pass newly classified crash loops to handle until ctx is done
+func (m *CrashLoopMonitor) Run(ctx context.Context, interval time.Duration, handle func(CrashLoop)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		loops, err := m.Poll()
+		if err != nil {
+			return err
+		}
+		for _, loop := range loops {
+			handle(loop)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CrashLoops Return the processes currently classified as crash looping
+func (m *CrashLoopMonitor) CrashLoops() []CrashLoop {
+	loops := make([]CrashLoop, 0, len(m.looping))
+	for _, loop := range m.looping {
+		loops = append(loops, loop)
+	}
+	sort.Slice(loops, func(i, j int) bool {
+		return loops[i].Name < loops[j].Name
+	})
+	return loops
+}
+
+// Release Forget the restart history of a process, e.g. after fixing a quarantined one
+func (m *CrashLoopMonitor) Release(name string) {
+	delete(m.restarts, name)
+	delete(m.looping, name)
+}
+
+// expire Drop restarts older than the window and return how many are left
+func (m *CrashLoopMonitor) expire(name string, now time.Time) int {
+	restarts := m.restarts[name]
+	i := 0
+	for i < len(restarts) && now.Sub(restarts[i]) > m.config.Window {
+		i++
+	}
+	restarts = restarts[i:]
+	if len(restarts) == 0 {
+		delete(m.restarts, name)
+		return 0
+	}
+	m.restarts[name] = restarts
+	return len(restarts)
+}
+
+func (m *CrashLoopMonitor) classify(info ProcessInfo, restarts int, now time.Time) CrashLoop {
+	loop := CrashLoop{
+		Name:       info.FullName(),
+		Restarts:   restarts,
+		Window:     m.config.Window,
+		State:      info.State,
+		StateName:  info.StateName,
+		ExitStatus: info.ExitStatus,
+		SpawnErr:   info.SpawnErr,
+		DetectedAt: now,
+	}
+	if tail, err := m.client.TailProcessStderrLog(loop.Name, 0, m.config.StderrBytes); err == nil {
+		content := tail.Content
+		if i := strings.IndexByte(content, '\n'); tail.Overflow && i >= 0 {
+			content = content[i+1:]
+		}
+		loop.StderrTail = lastLines(content, m.config.StderrLines)
+	}
+	if m.config.Quarantine {
+		// an EXITED or FATAL offender is already out of the loop
+		if err := m.client.StopProcess(loop.Name, true); err != nil && !errors.Is(err, ErrNotRunning) {
+			loop.QuarantineErr = err
+		}
+		loop.Quarantined = loop.QuarantineErr == nil
+	}
+	return loop
+}
+
+// lastLines Return up to n trailing non-empty lines of content
+func lastLines(content string, n int) []string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
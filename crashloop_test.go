@@ -0,0 +1,104 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrashLoopMonitor(t *testing.T) {
+	web := ProcessInfo{Name: "web", Group: "web", State: ProcessRunning, StateName: "RUNNING", Pid: 100, Start: 1000}
+	db := ProcessInfo{Name: "db", Group: "db", State: ProcessRunning, StateName: "RUNNING", Pid: 200, Start: 1000}
+	stopped := ""
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			return []ProcessInfo{web, db}, nil
+		},
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"partial\nTraceback\nValueError: boom\n", 4096, true}, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			stopped = args[0].(string)
+			return true, nil
+		},
+	})
+	now := time.Unix(5000, 0)
+	monitor := NewCrashLoopMonitor(ts.client(t), CrashLoopConfig{Window: time.Minute, MaxRestarts: 3, Quarantine: true})
+	monitor.now = func() time.Time { return now }
+
+	if _, err := monitor.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		now = now.Add(10 * time.Second)
+		web.Pid += 1
+		web.Start += 10
+		web.State, web.StateName, web.ExitStatus = ProcessExited, "EXITED", 1
+		loops, err := monitor.Poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < 3 && len(loops) != 0 {
+			t.Fatalf("classified after %d restarts", i)
+		}
+		if i == 3 {
+			if len(loops) != 1 {
+				t.Fatalf("expected one crash loop but %d", len(loops))
+			}
+			loop := loops[0]
+			if loop.Name != "web:web" || loop.Restarts != 3 || loop.ExitStatus != 1 {
+				t.Fatalf("unexpected crash loop %+v", loop)
+			}
+			if !loop.Quarantined || stopped != "web:web" {
+				t.Fatalf("expected web:web quarantined but stopped %q", stopped)
+			}
+			if len(loop.StderrTail) != 2 || loop.StderrTail[1] != "ValueError: boom" {
+				t.Fatalf("unexpected stderr tail %q", loop.StderrTail)
+			}
+		}
+	}
+	if loops := monitor.CrashLoops(); len(loops) != 1 {
+		t.Fatalf("expected web:web still classified but %v", loops)
+	}
+	monitor.Release("web:web")
+	if loops := monitor.CrashLoops(); len(loops) != 0 {
+		t.Fatalf("expected no crash loops after release but %v", loops)
+	}
+}
+
+func TestCrashLoopWindowExpires(t *testing.T) {
+	monitor := NewCrashLoopMonitor(nil, CrashLoopConfig{Window: time.Minute, MaxRestarts: 2})
+	now := time.Unix(5000, 0)
+	monitor.now = func() time.Time { return now }
+	restart := func(pid int) []Transition {
+		return []Transition{{
+			Name: "web:web",
+			From: ProcessInfo{Name: "web", Group: "web", Pid: pid - 1, Start: pid - 1},
+			To:   ProcessInfo{Name: "web", Group: "web", Pid: pid, Start: pid},
+		}}
+	}
+	monitor.Observe(restart(2))
+	now = now.Add(2 * time.Minute)
+	if loops := monitor.Observe(restart(3)); len(loops) != 0 {
+		t.Fatalf("restart outside the window counted: %v", loops)
+	}
+}
+
+func TestCrashLoopQuarantineExited(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", 0, false}, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			return nil, testFault{StatusNotRunning, "NOT_RUNNING"}
+		},
+	})
+	monitor := NewCrashLoopMonitor(ts.client(t), CrashLoopConfig{Window: time.Minute, MaxRestarts: 1, Quarantine: true})
+	loops := monitor.Observe([]Transition{{
+		Name: "web:web",
+		From: ProcessInfo{Name: "web", Group: "web", Pid: 1, Start: 1},
+		To:   ProcessInfo{Name: "web", Group: "web", State: ProcessFatal, StateName: "FATAL", Start: 2},
+	}})
+	if len(loops) != 1 || !loops[0].Quarantined || loops[0].QuarantineErr != nil {
+		t.Fatalf("expected FATAL process counted as quarantined but %+v", loops)
+	}
+}
@@ -0,0 +1,253 @@
+package supervisor
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DownloadOptions Tune a log download, zero values use the defaults
+type DownloadOptions struct {
+	Offset    int64 // resume from this byte offset of the log
+	ChunkSize int   // max bytes per read, default 64KB
+	Gzip      bool  // compress the output, a resumed download appends a new gzip member
+}
+
+// readLogFunc Read length bytes of a log starting at offset
+type readLogFunc func(offset, length int) (string, error)
+
+// DownloadProcessLog Copy name's stream log to w in bounded chunks, up to the
+// size the log had when the download started. The returned offset is where the
+// copy stopped, pass it as Offset to resume after an error.
+func (c *Client) DownloadProcessLog(w io.Writer, name string, stream LogStream, opts DownloadOptions) (int64, error) {
+	size, err := c.ProcessLogSize(name, stream)
+	if err != nil {
+		return opts.Offset, err
+	}
+	return downloadLog(w, opts, size, func(offset, length int) (string, error) {
+		return c.ReadProcessLog(name, stream, offset, length)
+	})
+}
+
+// DownloadLog Copy the main log to w in bounded chunks until its end, see
+// DownloadProcessLog for resuming
+func (c *Client) DownloadLog(w io.Writer, opts DownloadOptions) (int64, error) {
+	return downloadLog(w, opts, -1, c.ReadLog)
+}
+
+// ArchiveAndClearProcessLogs Copy the stdout and stderr logs of name to stdout
+// and stderr, then clear them. Each log is archived up to the size it had when
+// its copy started, output written after that until the clear is lost. The logs
+// are only cleared when both copies are complete, every write succeeded and
+// writers with a Sync method were synced. Offset is ignored as both logs are
+// archived from the start.
+func (c *Client) ArchiveAndClearProcessLogs(name string, stdout, stderr io.Writer, opts DownloadOptions) error {
+	opts.Offset = 0
+	streams := map[LogStream]io.Writer{StdoutStream: stdout, StderrStream: stderr}
+	for _, stream := range []LogStream{StdoutStream, StderrStream} {
+		size, err := c.archiveSize(name, stream)
+		if err != nil {
+			return fmt.Errorf("archive %s %s log: %w", name, stream, err)
+		}
+		read := noFileAsEmpty(func(offset, length int) (string, error) {
+			return c.ReadProcessLog(name, stream, offset, length)
+		})
+		if _, err := archiveLog(streams[stream], opts, size, read); err != nil {
+			return fmt.Errorf("archive %s %s log: %w", name, stream, err)
+		}
+	}
+	return c.ClearProcessLogs(name)
+}
+
+// ArchiveAndClearLog Copy the main log to w and clear it after a verified
+// write, see ArchiveAndClearProcessLogs. supervisord reports no size for the
+// main log, it is archived up to the end the copy reaches. Offset is ignored.
+func (c *Client) ArchiveAndClearLog(w io.Writer, opts DownloadOptions) error {
+	opts.Offset = 0
+	if _, err := archiveLog(w, opts, -1, c.ReadLog); err != nil {
+		return fmt.Errorf("archive main log: %w", err)
+	}
+	_, err := c.ClearLog()
+	return err
+}
+
+// ArchiveResult The archived logs of one process
+type ArchiveResult struct {
+	Name  string   // group:name
+	Files []string // archive files, stdout first
+	Bytes int64    // log bytes archived
+	Err   error    // the logs were not cleared when set
+}
+
+// ArchiveAndClearAllProcessLogs Archive the logs of every process to files in
+// dir named group_name.stream.TIMESTAMP.log(.gz), skipping logs supervisord has
+// no file for, e.g. stderr with redirect_stderr. Each process is cleared only
+// after its own archives are complete. Unlike ClearAllProcessLogs a failure
+// leaves that process alone and the others are still archived and cleared.
+// Offset is ignored.
+func (c *Client) ArchiveAndClearAllProcessLogs(dir string, opts DownloadOptions) ([]ArchiveResult, error) {
+	opts.Offset = 0
+	infos, err := c.GetAllProcessInfo()
+	if err != nil {
+		return nil, err
+	}
+	stamp := time.Now().Format("20060102T150405")
+	results := make([]ArchiveResult, 0, len(infos))
+	for _, info := range infos {
+		result := ArchiveResult{Name: info.FullName()}
+		result.Err = c.archiveProcessFiles(&result, dir, stamp, opts)
+		if result.Err == nil {
+			result.Err = c.ClearProcessLogs(result.Name)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *Client) archiveProcessFiles(result *ArchiveResult, dir, stamp string, opts DownloadOptions) error {
+	for _, stream := range []LogStream{StdoutStream, StderrStream} {
+		read := func(offset, length int) (string, error) {
+			return c.ReadProcessLog(result.Name, stream, offset, length)
+		}
+		size, err := processLogSize(c, result.Name, stream)
+		if errors.Is(err, ErrNoFile) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s.%s.log", strings.Replace(result.Name, ":", "_", -1), stream, stamp))
+		if opts.Gzip {
+			path += ".gz"
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, path)
+		n, err := archiveLog(f, opts, size, noFileAsEmpty(read))
+		result.Bytes += n
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("archive %s log to %s: %w", stream, path, err)
+		}
+	}
+	return nil
+}
+
+// archiveSize Return the size of a process log, 0 when supervisord has no file for it
+func (c *Client) archiveSize(name string, stream LogStream) (int64, error) {
+	size, err := processLogSize(c, name, stream)
+	if errors.Is(err, ErrNoFile) {
+		return 0, nil
+	}
+	return size, err
+}
+
+// noFileAsEmpty Read a log supervisord has no file for as empty, e.g. stderr of
+// a program with redirect_stderr or a log never written
+func noFileAsEmpty(read readLogFunc) readLogFunc {
+	return func(offset, length int) (string, error) {
+		content, err := read(offset, length)
+		if errors.Is(err, ErrNoFile) {
+			return "", nil
+		}
+		return content, err
+	}
+}
+
+func downloadLog(w io.Writer, opts DownloadOptions, size int64, read readLogFunc) (int64, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 64 * 1024
+	}
+	if !opts.Gzip {
+		return copyLog(w, opts.Offset, size, opts.ChunkSize, read)
+	}
+	zw := gzip.NewWriter(w)
+	offset, err := copyLog(zw, opts.Offset, size, opts.ChunkSize, read)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	return offset, err
+}
+
+// copyLog Copy the log from offset up to size, or until a short read when size
+// is negative, and return the offset reached. Writers with a Flush method, like
+// gzip.Writer, are flushed after every chunk.
+func copyLog(w io.Writer, offset, size int64, chunkSize int, read readLogFunc) (int64, error) {
+	for size < 0 || offset < size {
+		length := int64(chunkSize)
+		if size >= 0 && size-offset < length {
+			length = size - offset
+		}
+		content, err := read(int(offset), int(length))
+		if err != nil {
+			return offset, err
+		}
+		n, err := io.WriteString(w, content)
+		if flusher, ok := w.(interface{ Flush() error }); ok {
+			// buffered bytes count only once they reached the underlying writer
+			if err == nil {
+				err = flusher.Flush()
+			}
+			if err != nil {
+				return offset, err
+			}
+		}
+		offset += int64(n)
+		if err != nil {
+			return offset, err
+		}
+		if int64(len(content)) < length {
+			break
+		}
+	}
+	return offset, nil
+}
+
+// archiveLog Copy the log up to size, or up to the end the copy reaches when
+// size is negative, and verify the log did not shrink below the copied offset
+func archiveLog(w io.Writer, opts DownloadOptions, size int64, read readLogFunc) (int64, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 64 * 1024
+	}
+	out, zw := w, (*gzip.Writer)(nil)
+	if opts.Gzip {
+		zw = gzip.NewWriter(w)
+		out = zw
+	}
+	offset, err := copyLog(out, opts.Offset, size, opts.ChunkSize, read)
+	if err != nil {
+		return offset, err
+	}
+	if size >= 0 && offset < size {
+		return offset, fmt.Errorf("log shrank to %d while archiving %d bytes", offset, size)
+	}
+	if offset > 0 {
+		last, err := read(int(offset-1), 1)
+		if err != nil {
+			return offset, err
+		}
+		if last == "" {
+			return offset, fmt.Errorf("log shrank below offset %d while archiving", offset)
+		}
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return offset, err
+		}
+	}
+	if syncer, ok := w.(interface{ Sync() error }); ok {
+		if err := syncer.Sync(); err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
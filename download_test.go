@@ -0,0 +1,212 @@
+package supervisor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newDownloadServer(t *testing.T, logs map[string]string, cleared *int) *testServer {
+	read := func(log string, offset, length int) string {
+		if offset >= len(log) {
+			return ""
+		}
+		if end := offset + length; length > 0 && end < len(log) {
+			return log[offset:end]
+		}
+		return log[offset:]
+	}
+	return newTestServer(t, map[string]testHandler{
+		"supervisor.tailProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", len(logs["stdout"]), false}, nil
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return read(logs["stdout"], int(args[1].(int64)), int(args[2].(int64))), nil
+		},
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			log, ok := logs["stderr"]
+			if !ok {
+				return nil, testFault{StatusNoFile, "NO_FILE: stderr"}
+			}
+			return []interface{}{"", len(log), false}, nil
+		},
+		"supervisor.readProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			log, ok := logs["stderr"]
+			if !ok {
+				return nil, testFault{StatusNoFile, "NO_FILE: stderr"}
+			}
+			return read(log, int(args[1].(int64)), int(args[2].(int64))), nil
+		},
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			return []ProcessInfo{{Name: "web", Group: "web", State: ProcessRunning, StateName: "RUNNING"}}, nil
+		},
+		"supervisor.readLog": func(args []interface{}) (interface{}, error) {
+			return read(logs["main"], int(args[0].(int64)), int(args[1].(int64))), nil
+		},
+		"supervisor.clearProcessLogs": func(args []interface{}) (interface{}, error) {
+			*cleared++
+			return true, nil
+		},
+		"supervisor.clearLog": func(args []interface{}) (interface{}, error) {
+			*cleared++
+			return true, nil
+		},
+	})
+}
+
+type failingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errors.New("disk full")
+	}
+	return w.buf.Write(p)
+}
+
+func TestDownloadProcessLog(t *testing.T) {
+	logs := map[string]string{"stdout": strings.Repeat("0123456789", 10)}
+	cleared := 0
+	cli := newDownloadServer(t, logs, &cleared).client(t)
+
+	w := &failingWriter{limit: 40}
+	offset, err := cli.DownloadProcessLog(w, "web", StdoutStream, DownloadOptions{ChunkSize: 7})
+	if err == nil || offset != 35 {
+		t.Fatalf("expected to stop at 35 with an error but %d %v", offset, err)
+	}
+	w.limit = 1000
+	offset, err = cli.DownloadProcessLog(w, "web", StdoutStream, DownloadOptions{ChunkSize: 7, Offset: offset})
+	if err != nil || offset != 100 || w.buf.String() != logs["stdout"] {
+		t.Fatalf("resume failed: %d %v %q", offset, err, w.buf.String())
+	}
+
+	var buf bytes.Buffer
+	logs["main"] = "main log\n"
+	if _, err := cli.DownloadLog(&buf, DownloadOptions{ChunkSize: 4, Gzip: true}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadAll(zr); string(data) != "main log\n" {
+		t.Fatalf("unexpected gzip content %q", data)
+	}
+}
+
+func TestArchiveAndClearProcessLogs(t *testing.T) {
+	logs := map[string]string{"stdout": "out\n", "stderr": "err\n"}
+	cleared := 0
+	cli := newDownloadServer(t, logs, &cleared).client(t)
+
+	var stdout bytes.Buffer
+	if err := cli.ArchiveAndClearProcessLogs("web", &stdout, &failingWriter{limit: 2}, DownloadOptions{}); err == nil {
+		t.Fatal("expected the failed stderr write to be reported")
+	}
+	if cleared != 0 {
+		t.Fatal("logs cleared after a failed write")
+	}
+	var stderr bytes.Buffer
+	stdout.Reset()
+	if err := cli.ArchiveAndClearProcessLogs("web", &stdout, &stderr, DownloadOptions{ChunkSize: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 1 || stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Fatalf("unexpected archive %q %q cleared %d", stdout.String(), stderr.String(), cleared)
+	}
+}
+
+func TestArchiveAndClearChattyProcess(t *testing.T) {
+	logs := map[string]string{"stdout": "0\n", "stderr": ""}
+	cleared := 0
+	ts := newDownloadServer(t, logs, &cleared)
+	reads := 0
+	ts.handle("supervisor.readProcessStdoutLog", func(args []interface{}) (interface{}, error) {
+		// every read finds a new line appended
+		log := logs["stdout"]
+		logs["stdout"] += strconv.Itoa(reads) + "\n"
+		reads++
+		offset, length := int(args[1].(int64)), int(args[2].(int64))
+		if offset >= len(log) {
+			return "", nil
+		}
+		if end := offset + length; end < len(log) {
+			return log[offset:end], nil
+		}
+		return log[offset:], nil
+	})
+	var stdout, stderr bytes.Buffer
+	if err := ts.client(t).ArchiveAndClearProcessLogs("web", &stdout, &stderr, DownloadOptions{ChunkSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 1 || stdout.String() != "0\n" {
+		t.Fatalf("expected the log archived up to its size at the start but %q cleared %d", stdout.String(), cleared)
+	}
+}
+
+func TestArchiveAndClearLogIgnoresOffset(t *testing.T) {
+	logs := map[string]string{"main": "line 1\nline 2\n"}
+	cleared := 0
+	cli := newDownloadServer(t, logs, &cleared).client(t)
+	var archive bytes.Buffer
+	if err := cli.ArchiveAndClearLog(&archive, DownloadOptions{Offset: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 1 || archive.String() != logs["main"] {
+		t.Fatalf("expected the whole log archived before the clear but %q cleared %d", archive.String(), cleared)
+	}
+}
+
+func TestArchiveAndClearAllProcessLogsWithoutStderr(t *testing.T) {
+	logs := map[string]string{"stdout": "out\n"}
+	cleared := 0
+	cli := newDownloadServer(t, logs, &cleared).client(t)
+	dir := t.TempDir()
+	results, err := cli.ArchiveAndClearAllProcessLogs(dir, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err != nil || len(results[0].Files) != 1 || cleared != 1 {
+		t.Fatalf("expected only stdout archived and the logs cleared but %+v cleared %d", results, cleared)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("expected no stderr archive file but %d files", len(files))
+	}
+	var stderr bytes.Buffer
+	if err := cli.ArchiveAndClearProcessLogs("web", &bytes.Buffer{}, &stderr, DownloadOptions{}); err != nil || cleared != 2 {
+		t.Fatalf("expected a missing stderr log to archive as empty but %v", err)
+	}
+}
+
+func TestDownloadGzipResumeOffset(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	line := make([]byte, 0, 600)
+	for len(line) < cap(line) {
+		line = append(line, byte('!'+random.Intn(90)))
+	}
+	log := string(line)
+	logs := map[string]string{"stdout": log}
+	cleared := 0
+	cli := newDownloadServer(t, logs, &cleared).client(t)
+	w := &failingWriter{limit: 300}
+	offset, err := cli.DownloadProcessLog(w, "web", StdoutStream, DownloadOptions{ChunkSize: 50, Gzip: true})
+	if err == nil || offset >= int64(len(log)) {
+		t.Fatalf("expected the write to fail before the end but %d %v", offset, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(w.buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(zr)
+	if int64(len(data)) < offset || string(data[:offset]) != log[:offset] {
+		t.Fatalf("offset %d passed the %d bytes that reached the writer", offset, len(data))
+	}
+}
@@ -0,0 +1,197 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// StdinWriter Send everything written to the stdin of a process with
+// SendProcessStdin, split in chunks of at most ChunkSize bytes on rune boundaries
+type StdinWriter struct {
+	client    Control
+	name      string
+	ChunkSize int // default 16KB
+	mu        sync.Mutex
+	closed    bool
+}
+
+// ProcessStdin Return a writer for the stdin of name
+func (c *Client) ProcessStdin(name string) *StdinWriter {
+	return newStdinWriter(c, name)
+}
+
+func newStdinWriter(client Control, name string) *StdinWriter {
+	return &StdinWriter{client: client, name: name, ChunkSize: 16 * 1024}
+}
+
+// Write Send p, the count is the bytes of the chunks that were accepted
+func (w *StdinWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	chunkSize := w.ChunkSize
+	if chunkSize < utf8.UTFMax {
+		chunkSize = 16 * 1024
+	}
+	n := 0
+	for n < len(p) {
+		end := n + chunkSize
+		if end >= len(p) {
+			end = len(p)
+		} else {
+			for end > n && !utf8.RuneStart(p[end]) {
+				end--
+			}
+		}
+		if err := w.client.SendProcessStdin(w.name, string(p[n:end])); err != nil {
+			return n, err
+		}
+		n = end
+	}
+	return n, nil
+}
+
+// Close Refuse further writes, supervisord cannot close the stdin of a process
+func (w *StdinWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+// ExpectMatch The output read until a pattern matched
+type ExpectMatch struct {
+	Offset int64    // stdout log offset the output starts at
+	Output string   // output up to the end of the match
+	Groups []string // the match and its submatches
+}
+
+// ExpectTimeoutError The pattern did not show up before the timeout
+type ExpectTimeoutError struct {
+	Pattern string
+	Output  string // everything read while waiting
+}
+
+func (e *ExpectTimeoutError) Error() string {
+	return fmt.Sprintf("timeout waiting for %q", e.Pattern)
+}
+
+// Unwrap Let errors.Is match context.DeadlineExceeded
+func (e *ExpectTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Expecter Script a process through its stdin and stdout like expect. Output is
+// consumed up to the end of every match, the next Expect continues from there.
+type Expecter struct {
+	client   Interface
+	name     string
+	stdin    *StdinWriter
+	offset   int64
+	Interval time.Duration // stdout poll interval, default 100ms
+	ReadSize int           // max bytes per read, default 64KB
+}
+
+// NewExpecter Create an expecter for name starting at the current end of its stdout log
+func NewExpecter(client Interface, name string) (*Expecter, error) {
+	offset, err := processLogSize(client, name, StdoutStream)
+	if err != nil {
+		return nil, err
+	}
+	return &Expecter{
+		client:   client,
+		name:     name,
+		stdin:    newStdinWriter(client, name),
+		offset:   offset,
+		Interval: 100 * time.Millisecond,
+		ReadSize: 64 * 1024,
+	}, nil
+}
+
+// Offset Return the stdout log offset the next Expect reads from
+func (e *Expecter) Offset() int64 {
+	return e.offset
+}
+
+// Send Send input to the process, large input is chunked
+func (e *Expecter) Send(input string) error {
+	_, err := io.WriteString(e.stdin, input)
+	return err
+}
+
+// SendExpect Send input and wait for pattern in the output written after the
+// send, unread output from before is skipped
+func (e *Expecter) SendExpect(ctx context.Context, input string, pattern *regexp.Regexp, timeout time.Duration) (*ExpectMatch, error) {
+	offset, err := processLogSize(e.client, e.name, StdoutStream)
+	if err != nil {
+		return nil, err
+	}
+	e.offset = offset
+	if err := e.Send(input); err != nil {
+		return nil, err
+	}
+	return e.Expect(ctx, pattern, timeout)
+}
+
+// Expect Wait until the stdout output after the offset matches pattern, a zero
+// timeout waits until ctx is done. A timeout returns *ExpectTimeoutError.
+func (e *Expecter) Expect(ctx context.Context, pattern *regexp.Regexp, timeout time.Duration) (*ExpectMatch, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	interval, readSize := e.Interval, e.ReadSize
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	if readSize <= 0 {
+		readSize = 64 * 1024
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var output strings.Builder
+	offset := e.offset
+	for {
+		for {
+			content, err := e.client.ReadProcessStdoutLog(e.name, int(offset), readSize)
+			if err != nil {
+				return nil, err
+			}
+			output.WriteString(content)
+			offset += int64(len(content))
+			if len(content) < readSize {
+				break
+			}
+		}
+		text := output.String()
+		if loc := pattern.FindStringSubmatchIndex(text); loc != nil {
+			match := &ExpectMatch{Offset: e.offset, Output: text[:loc[1]]}
+			for i := 0; i < len(loc); i += 2 {
+				group := ""
+				if loc[i] >= 0 {
+					group = text[loc[i]:loc[i+1]]
+				}
+				match.Groups = append(match.Groups, group)
+			}
+			e.offset += int64(loc[1])
+			return match, nil
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, &ExpectTimeoutError{Pattern: pattern.String(), Output: text}
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
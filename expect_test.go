@@ -0,0 +1,102 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func newConsoleServer(t *testing.T, respond func(input string) string) (*testServer, func() []string) {
+	var mu sync.Mutex
+	stdout, sent := "welcome\n> ", make([]string, 0)
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.tailProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return []interface{}{"", len(stdout), false}, nil
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			offset, length := int(args[1].(int64)), int(args[2].(int64))
+			if offset >= len(stdout) {
+				return "", nil
+			}
+			if end := offset + length; end < len(stdout) {
+				return stdout[offset:end], nil
+			}
+			return stdout[offset:], nil
+		},
+		"supervisor.sendProcessStdin": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			input := args[1].(string)
+			sent = append(sent, input)
+			stdout += respond(input)
+			return true, nil
+		},
+	})
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), sent...)
+	}
+}
+
+func TestExpecter(t *testing.T) {
+	ts, _ := newConsoleServer(t, func(input string) string {
+		if input == "status\n" {
+			return "uptime 42s\nconnections 7\n> "
+		}
+		return "unknown command\n> "
+	})
+	e, err := NewExpecter(ts.client(t), "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Interval = 5 * time.Millisecond
+	match, err := e.SendExpect(context.Background(), "status\n", regexp.MustCompile(`connections (\d+)`), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Output != "uptime 42s\nconnections 7" || match.Groups[1] != "7" || match.Offset != int64(len("welcome\n> ")) {
+		t.Fatalf("unexpected match %+v", match)
+	}
+	// the rest of the output is still there for the next Expect
+	if _, err := e.Expect(context.Background(), regexp.MustCompile(`^\n> $`), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.SendExpect(context.Background(), "bogus\n", regexp.MustCompile(`connections`), 30*time.Millisecond)
+	var timeout *ExpectTimeoutError
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) || timeout.Output != "unknown command\n> " {
+		t.Fatalf("expected a timeout with the output but %v", err)
+	}
+}
+
+func TestStdinWriterChunks(t *testing.T) {
+	ts, sent := newConsoleServer(t, func(string) string { return "" })
+	w := ts.client(t).ProcessStdin("console")
+	w.ChunkSize = 5
+	payload := strings.Repeat("héllo wörld ", 3)
+	if n, err := w.Write([]byte(payload)); err != nil || n != len(payload) {
+		t.Fatalf("write failed: %d %v", n, err)
+	}
+	chunks := sent()
+	for _, chunk := range chunks {
+		if len(chunk) > 5 || !utf8.ValidString(chunk) {
+			t.Fatalf("invalid chunk %q", chunk)
+		}
+	}
+	if strings.Join(chunks, "") != payload {
+		t.Fatalf("chunks do not add up: %q", chunks)
+	}
+	w.Close()
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatal("expected an error writing to a closed stdin")
+	}
+}
@@ -0,0 +1,192 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// EscalationStep One rung of a stop ladder: send Signal, then wait up to Wait
+// for the process to go away
+type EscalationStep struct {
+	Signal Signal
+	Wait   time.Duration
+}
+
+// StopPolicy The signals tried in order before falling back to StopProcess
+type StopPolicy struct {
+	Steps []EscalationStep
+}
+
+// DefaultStopPolicy TERM for a drain, then QUIT, then KILL
+var DefaultStopPolicy = StopPolicy{Steps: []EscalationStep{
+	{Signal: SignalTERM, Wait: 30 * time.Second},
+	{Signal: SignalQUIT, Wait: 10 * time.Second},
+	{Signal: SignalKILL, Wait: 5 * time.Second},
+}}
+
+// StopResult How a graceful stop ended
+type StopResult struct {
+	Name           string
+	Step           int    // index of the step that stopped the process, -1 when already stopped, len(Steps) for the fallback
+	Signal         Signal // signal of that step, 0 otherwise
+	Fallback       bool   // no step worked, StopProcess did
+	AlreadyStopped bool
+	Restarted      bool // supervisord respawned the signalled process (autorestart), the new one was stopped with StopProcess
+	Elapsed        time.Duration
+	State          ProcessState
+}
+
+// GracefulStopper Stop processes by walking a per-program escalation ladder
+// with SignalProcessNamed, polling GetProcessInfo between steps, before falling back
+// to StopProcess
+type GracefulStopper struct {
+	client       Interface
+	policies     map[string]StopPolicy
+	Default      StopPolicy
+	PollInterval time.Duration // default 500 milliseconds
+	// RespawnWait How long a process that exited from a signal is watched for a
+	// respawn by autorestart, default 2 seconds as supervisord respawns on its
+	// next tick of up to a second
+	RespawnWait time.Duration
+	now         func() time.Time
+}
+
+// NewGracefulStopper Create a stopper using policy for programs without their own
+func NewGracefulStopper(client Interface, policy StopPolicy) *GracefulStopper {
+	return &GracefulStopper{
+		client:       client,
+		policies:     make(map[string]StopPolicy),
+		Default:      policy,
+		PollInterval: 500 * time.Millisecond,
+		RespawnWait:  2 * time.Second,
+		now:          time.Now,
+	}
+}
+
+// SetPolicy Set the policy of name, either group:name or a group applying to all its processes
+func (g *GracefulStopper) SetPolicy(name string, policy StopPolicy) {
+	g.policies[name] = policy
+}
+
+// Policy Return the policy used for name
+func (g *GracefulStopper) Policy(name string) StopPolicy {
+	if policy, ok := g.policies[name]; ok {
+		return policy
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		if policy, ok := g.policies[name[:i]]; ok {
+			return policy
+		}
+	}
+	return g.Default
+}
+
+// Stop Stop name with its policy and report which step stopped it
+func (g *GracefulStopper) Stop(ctx context.Context, name string) (*StopResult, error) {
+	start := g.now()
+	result := &StopResult{Name: name, Step: -1}
+	info, err := g.client.GetProcessInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.State.IsRunning() && info.State != ProcessStopping {
+		result.AlreadyStopped, result.State = true, info.State
+		result.Elapsed = g.now().Sub(start)
+		return result, nil
+	}
+	steps := g.Policy(name).Steps
+	for i, step := range steps {
+		err := g.client.SignalProcessNamed(name, step.Signal)
+		if errors.Is(err, ErrNotRunning) {
+			// e.g. BACKOFF has no process to signal, waiting cannot help
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		current, stopped, err := g.waitStopped(ctx, name, info, step.Wait)
+		if err != nil {
+			return nil, err
+		}
+		if stopped {
+			result.Step, result.Signal = i, step.Signal
+			if !current.State.IsRunning() {
+				if current, err = g.watchRespawn(ctx, name, current); err != nil {
+					return nil, err
+				}
+			}
+			return g.finish(result, info, current, start)
+		}
+	}
+	if err := g.client.StopProcess(name, true); err != nil && !errors.Is(err, ErrNotRunning) {
+		return nil, err
+	}
+	current, err := g.client.GetProcessInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	result.Step, result.Fallback = len(steps), true
+	result.State = current.State
+	result.Elapsed = g.now().Sub(start)
+	return result, nil
+}
+
+// finish Stop a respawned process again and fill in the final state
+func (g *GracefulStopper) finish(result *StopResult, before, current ProcessInfo, start time.Time) (*StopResult, error) {
+	if current.State.IsRunning() && current.Pid != before.Pid {
+		result.Restarted = true
+		if err := g.client.StopProcess(result.Name, true); err != nil && !errors.Is(err, ErrNotRunning) {
+			return nil, err
+		}
+		var err error
+		if current, err = g.client.GetProcessInfo(result.Name); err != nil {
+			return nil, err
+		}
+	}
+	result.State = current.State
+	result.Elapsed = g.now().Sub(start)
+	return result, nil
+}
+
+// watchRespawn Poll an exited process for RespawnWait and return it as soon as
+// autorestart brought it back
+func (g *GracefulStopper) watchRespawn(ctx context.Context, name string, exited ProcessInfo) (ProcessInfo, error) {
+	info := exited
+	deadline := g.now().Add(g.RespawnWait)
+	for g.now().Before(deadline) {
+		if err := sleepContext(ctx, g.PollInterval); err != nil {
+			return info, err
+		}
+		var err error
+		if info, err = g.client.GetProcessInfo(name); err != nil {
+			return info, err
+		}
+		if info.State.IsRunning() {
+			return info, nil
+		}
+	}
+	return info, nil
+}
+
+// waitStopped Poll until the process signalled as before is gone or wait elapsed.
+// Gone means not running any more, or running with another pid after a respawn.
+func (g *GracefulStopper) waitStopped(ctx context.Context, name string, before ProcessInfo, wait time.Duration) (ProcessInfo, bool, error) {
+	deadline := g.now().Add(wait)
+	for {
+		info, err := g.client.GetProcessInfo(name)
+		if err != nil {
+			return info, false, err
+		}
+		if (!info.State.IsRunning() && info.State != ProcessStopping) || info.Pid != before.Pid {
+			return info, true, nil
+		}
+		if !g.now().Before(deadline) {
+			return info, false, nil
+		}
+		if err := sleepContext(ctx, g.PollInterval); err != nil {
+			return info, false, err
+		}
+	}
+}
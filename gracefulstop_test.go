@@ -0,0 +1,148 @@
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStoppableServer simulates web:web which dies on the signals in dies and
+// respawns with a new pid after respawn polls of EXITED, never when negative
+func newStoppableServer(t *testing.T, dies map[string]bool, respawn int) (*testServer, func() []string) {
+	var mu sync.Mutex
+	info := ProcessInfo{Name: "web", Group: "web", State: ProcessRunning, Pid: 100}
+	signals := make([]string, 0)
+	exitedPolls := -1
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if exitedPolls >= 0 && info.State == ProcessExited {
+				if exitedPolls == 0 {
+					info.State, info.Pid = ProcessRunning, 101
+				}
+				exitedPolls--
+			}
+			return info, nil
+		},
+		"supervisor.signalProcess": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			signal := args[1].(string)
+			signals = append(signals, signal)
+			if dies[signal] {
+				info.State, info.Pid = ProcessExited, 0
+				if respawn == 0 {
+					info.State, info.Pid = ProcessRunning, 101
+				}
+				exitedPolls = respawn
+			}
+			return true, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			signals = append(signals, "stopProcess")
+			info.State, info.Pid = ProcessStopped, 0
+			return true, nil
+		},
+	})
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), signals...)
+	}
+}
+
+func TestGracefulStop(t *testing.T) {
+	ladder := StopPolicy{Steps: []EscalationStep{
+		{Signal: SignalTERM, Wait: 20 * time.Millisecond},
+		{Signal: SignalQUIT, Wait: 20 * time.Millisecond},
+	}}
+	ts, signals := newStoppableServer(t, map[string]bool{"QUIT": true}, -1)
+	stopper := NewGracefulStopper(ts.client(t), DefaultStopPolicy)
+	stopper.SetPolicy("web", ladder)
+	stopper.PollInterval, stopper.RespawnWait = 5*time.Millisecond, 20*time.Millisecond
+	result, err := stopper.Stop(context.Background(), "web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Step != 1 || result.Signal != SignalQUIT || result.Fallback || result.State != ProcessExited {
+		t.Fatalf("expected QUIT to stop the process but %+v", result)
+	}
+	if got := signals(); len(got) != 2 || got[0] != "TERM" {
+		t.Fatalf("unexpected signals %v", got)
+	}
+	result, err = stopper.Stop(context.Background(), "web:web")
+	if err != nil || !result.AlreadyStopped || result.Step != -1 {
+		t.Fatalf("expected an already stopped result but %+v %v", result, err)
+	}
+}
+
+func TestGracefulStopFallback(t *testing.T) {
+	ts, signals := newStoppableServer(t, nil, -1)
+	stopper := NewGracefulStopper(ts.client(t), StopPolicy{Steps: []EscalationStep{{Signal: SignalTERM, Wait: 10 * time.Millisecond}}})
+	stopper.PollInterval = 5 * time.Millisecond
+	result, err := stopper.Stop(context.Background(), "web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Fallback || result.Step != 1 || result.State != ProcessStopped {
+		t.Fatalf("expected the StopProcess fallback but %+v", result)
+	}
+	if got := signals(); len(got) != 2 || got[1] != "stopProcess" {
+		t.Fatalf("unexpected signals %v", got)
+	}
+
+	// a respawned process is stopped for good
+	ts, signals = newStoppableServer(t, map[string]bool{"TERM": true}, 0)
+	stopper = NewGracefulStopper(ts.client(t), StopPolicy{Steps: []EscalationStep{{Signal: SignalTERM, Wait: time.Second}}})
+	result, err = stopper.Stop(context.Background(), "web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Restarted || result.Step != 0 || result.State != ProcessStopped {
+		t.Fatalf("expected the respawned process to be stopped but %+v", result)
+	}
+}
+
+func TestGracefulStopLateRespawn(t *testing.T) {
+	ts, signals := newStoppableServer(t, map[string]bool{"TERM": true}, 2)
+	stopper := NewGracefulStopper(ts.client(t), StopPolicy{Steps: []EscalationStep{{Signal: SignalTERM, Wait: time.Second}}})
+	stopper.PollInterval, stopper.RespawnWait = 5*time.Millisecond, 200*time.Millisecond
+	result, err := stopper.Stop(context.Background(), "web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Restarted || result.Step != 0 || result.State != ProcessStopped {
+		t.Fatalf("expected the process respawned after EXITED to be stopped but %+v", result)
+	}
+	if got := signals(); len(got) != 2 || got[1] != "stopProcess" {
+		t.Fatalf("unexpected signals %v", got)
+	}
+}
+
+func TestGracefulStopBackoff(t *testing.T) {
+	ts, signals := newStoppableServer(t, nil, -1)
+	ts.handle("supervisor.getProcessInfo", func(args []interface{}) (interface{}, error) {
+		state := ProcessBackoff
+		if got := signals(); len(got) > 0 && got[len(got)-1] == "stopProcess" {
+			state = ProcessStopped
+		}
+		return ProcessInfo{Name: "web", Group: "web", State: state}, nil
+	})
+	ts.handle("supervisor.signalProcess", func(args []interface{}) (interface{}, error) {
+		return nil, testFault{StatusNotRunning, "NOT_RUNNING"}
+	})
+	stopper := NewGracefulStopper(ts.client(t), DefaultStopPolicy)
+	stopper.PollInterval = 5 * time.Millisecond
+	begin := time.Now()
+	result, err := stopper.Stop(context.Background(), "web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Fallback || result.State != ProcessStopped || time.Since(begin) > 5*time.Second {
+		t.Fatalf("expected an unsignallable process stopped right away but %+v after %v", result, time.Since(begin))
+	}
+}
@@ -0,0 +1,275 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Probe Check whether a service answers, a nil error means healthy
+type Probe interface {
+	Check(ctx context.Context) error
+}
+
+// HTTPProbe Healthy when a GET of URL answers with a 2xx or 3xx status code
+type HTTPProbe struct {
+	URL    string
+	Client *http.Client // nil means http.DefaultClient
+}
+
+func (p *HTTPProbe) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return err
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s: bad status code %d", p.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// TCPProbe Healthy when a TCP connection to Addr can be opened
+type TCPProbe struct {
+	Addr string // host:port
+}
+
+func (p *TCPProbe) Check(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.Addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// ExecProbe Healthy when the command exits with status 0
+type ExecProbe struct {
+	Path string
+	Args []string
+}
+
+func (p *ExecProbe) Check(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, p.Path, p.Args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", p.Path, err, out)
+	}
+	return nil
+}
+
+// HealthAction What to do with a process failing its health check
+type HealthAction int
+
+const (
+	HealthActionNone    HealthAction = iota // only record the failure
+	HealthActionRestart                     // StopProcess then StartProcess
+	HealthActionSignal                      // SignalProcessNamed with HealthCheck.Signal
+)
+
+// HealthCheck A liveness probe for one process, zero values use the defaults
+type HealthCheck struct {
+	Name             string // group:name
+	Probe            Probe
+	Interval         time.Duration // default 10 seconds
+	Timeout          time.Duration // default 5 seconds
+	FailureThreshold int           // consecutive failures before Action, default 3
+	Action           HealthAction
+	Signal           Signal
+	HistorySize      int // probe results kept, default 10
+}
+
+// ProbeResult The outcome of one probe run
+type ProbeResult struct {
+	Time     time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Health The current health of one process
+type Health struct {
+	Name                string
+	Healthy             bool
+	ConsecutiveFailures int
+	Actions             int // times Action was applied
+	LastActionErr       error
+	History             []ProbeResult // oldest first
+}
+
+type healthState struct {
+	check  HealthCheck
+	health Health
+}
+
+// HealthChecker Run liveness probes per process and restart or signal the
+// processes failing them. Processes which are not RUNNING are not probed.
+type HealthChecker struct {
+	client Interface
+	mu     sync.Mutex
+	states map[string]*healthState
+}
+
+// NewHealthChecker Create a health checker for checks, every check needs a Probe
+func NewHealthChecker(client Interface, checks ...HealthCheck) (*HealthChecker, error) {
+	h := &HealthChecker{
+		client: client,
+		states: make(map[string]*healthState, len(checks)),
+	}
+	for _, check := range checks {
+		if check.Probe == nil {
+			return nil, fmt.Errorf("health check %s has no probe", check.Name)
+		}
+		if check.Interval <= 0 {
+			check.Interval = 10 * time.Second
+		}
+		if check.Timeout <= 0 {
+			check.Timeout = 5 * time.Second
+		}
+		if check.FailureThreshold <= 0 {
+			check.FailureThreshold = 3
+		}
+		if check.HistorySize <= 0 {
+			check.HistorySize = 10
+		}
+		h.states[check.Name] = &healthState{
+			check:  check,
+			health: Health{Name: check.Name, Healthy: true},
+		}
+	}
+	return h, nil
+}
+
+// Run Probe every process on its own interval until ctx is done
+func (h *HealthChecker) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for name, state := range h.states {
+		wg.Add(1)
+		go func(name string, interval time.Duration) {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				h.CheckNow(ctx, name)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(name, state.check.Interval)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// CheckNow Run the probe of name once and apply the action if the threshold is reached
+func (h *HealthChecker) CheckNow(ctx context.Context, name string) (ProbeResult, error) {
+	h.mu.Lock()
+	state, ok := h.states[name]
+	h.mu.Unlock()
+	if !ok {
+		return ProbeResult{}, fmt.Errorf("no health check for %s", name)
+	}
+	info, err := h.client.GetProcessInfo(name)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	if info.State != ProcessRunning {
+		h.mu.Lock()
+		state.health.ConsecutiveFailures = 0
+		h.mu.Unlock()
+		return ProbeResult{}, nil
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, state.check.Timeout)
+	start := time.Now()
+	result := ProbeResult{Time: start, Err: state.check.Probe.Check(probeCtx)}
+	result.Duration = time.Since(start)
+	cancel()
+
+	h.mu.Lock()
+	health := &state.health
+	health.History = append(health.History, result)
+	if n := len(health.History) - state.check.HistorySize; n > 0 {
+		health.History = health.History[n:]
+	}
+	if result.Err == nil {
+		health.Healthy = true
+		health.ConsecutiveFailures = 0
+		h.mu.Unlock()
+		return result, nil
+	}
+	health.ConsecutiveFailures++
+	act := health.ConsecutiveFailures >= state.check.FailureThreshold
+	if act {
+		health.Healthy = false
+	}
+	h.mu.Unlock()
+
+	if act && state.check.Action != HealthActionNone {
+		err := h.act(state.check)
+		h.mu.Lock()
+		health.Actions++
+		health.LastActionErr = err
+		health.ConsecutiveFailures = 0
+		h.mu.Unlock()
+	}
+	return result, nil
+}
+
+func (h *HealthChecker) act(check HealthCheck) error {
+	switch check.Action {
+	case HealthActionRestart:
+		// the process may have died since it was probed
+		if err := h.client.StopProcess(check.Name, true); err != nil && !errors.Is(err, ErrNotRunning) {
+			return err
+		}
+		return h.client.StartProcess(check.Name, true)
+	case HealthActionSignal:
+		return h.client.SignalProcessNamed(check.Name, check.Signal)
+	}
+	return nil
+}
+
+// Health Return the current health of the process name
+func (h *HealthChecker) Health(name string) (Health, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state, ok := h.states[name]
+	if !ok {
+		return Health{}, false
+	}
+	health := state.health
+	health.History = append([]ProbeResult(nil), health.History...)
+	return health, true
+}
+
+// AllHealth Return the current health of every checked process sorted by name
+func (h *HealthChecker) AllHealth() []Health {
+	h.mu.Lock()
+	names := make([]string, 0, len(h.states))
+	for name := range h.states {
+		names = append(names, name)
+	}
+	h.mu.Unlock()
+	sort.Strings(names)
+	all := make([]Health, 0, len(names))
+	for _, name := range names {
+		health, _ := h.Health(name)
+		all = append(all, health)
+	}
+	return all
+}
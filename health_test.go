@@ -0,0 +1,120 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type scriptedProbe struct {
+	results []error
+}
+
+func (p *scriptedProbe) Check(ctx context.Context) error {
+	err := p.results[0]
+	p.results = p.results[1:]
+	return err
+}
+
+func TestHealthCheckerRestarts(t *testing.T) {
+	var calls []string
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			return ProcessInfo{Name: "web", Group: "web", State: ProcessRunning}, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			calls = append(calls, "stop")
+			return true, nil
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			calls = append(calls, "start")
+			return true, nil
+		},
+	})
+	down := errors.New("connection refused")
+	probe := &scriptedProbe{results: []error{down, nil, down, down, nil}}
+	checker, err := NewHealthChecker(ts.client(t), HealthCheck{
+		Name:             "web:web",
+		Probe:            probe,
+		FailureThreshold: 2,
+		Action:           HealthActionRestart,
+		HistorySize:      3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if _, err := checker.CheckNow(ctx, "web:web"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	health, ok := checker.Health("web:web")
+	if !ok {
+		t.Fatal("missing health")
+	}
+	if health.Healthy || health.Actions != 1 || len(calls) != 2 || calls[0] != "stop" || calls[1] != "start" {
+		t.Fatalf("expected one restart but health %+v calls %v", health, calls)
+	}
+	if len(health.History) != 3 {
+		t.Fatalf("expected history trimmed to 3 but %d", len(health.History))
+	}
+	checker.CheckNow(ctx, "web:web")
+	if health, _ := checker.Health("web:web"); !health.Healthy {
+		t.Fatal("expected healthy after successful probe")
+	}
+}
+
+func TestHealthCheckerRestartDeadProcess(t *testing.T) {
+	var started bool
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			return ProcessInfo{Name: "web", Group: "web", State: ProcessRunning}, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			return nil, testFault{StatusNotRunning, "NOT_RUNNING"}
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			started = true
+			return true, nil
+		},
+	})
+	checker, err := NewHealthChecker(ts.client(t), HealthCheck{
+		Name:             "web:web",
+		Probe:            &scriptedProbe{results: []error{errors.New("connection refused")}},
+		FailureThreshold: 1,
+		Action:           HealthActionRestart,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.CheckNow(context.Background(), "web:web"); err != nil {
+		t.Fatal(err)
+	}
+	if health, _ := checker.Health("web:web"); health.LastActionErr != nil || !started {
+		t.Fatalf("expected restart despite NOT_RUNNING but health %+v started %v", health, started)
+	}
+}
+
+func TestHealthCheckerRequiresProbe(t *testing.T) {
+	if _, err := NewHealthChecker(nil, HealthCheck{Name: "web:web"}); err == nil {
+		t.Fatal("expected error for a check without probe")
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	probe := &TCPProbe{Addr: addr}
+	if err := probe.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	if err := probe.Check(context.Background()); err == nil {
+		t.Fatal("expected closed port to be unhealthy")
+	}
+}
@@ -0,0 +1,66 @@
+package supervisor
+
+import "syscall"
+
+// ReadOnly The Client methods that only read from supervisord
+type ReadOnly interface {
+	ListMethods() ([]string, error)
+	MethodHelp(name string) (string, error)
+	MethodSignature(name string) ([]MethodSignature, error)
+	Capabilities() (*Capabilities, error)
+	Supports(method string) (bool, error)
+	GetAPIVersion() (string, error)
+	GetSupervisorVersion() (string, error)
+	GetIdentification() (string, error)
+	GetState() (ServerState, error)
+	GetPID() (int, error)
+	ReadLog(offset, length int) (string, error)
+	GetAllConfigInfo() ([]ProgramConfig, error)
+	GetProcessInfo(name string) (ProcessInfo, error)
+	GetAllProcessInfo() ([]ProcessInfo, error)
+	ReadProcessStdoutLog(name string, offset, length int) (string, error)
+	ReadProcessStderrLog(name string, offset, length int) (string, error)
+	TailProcessStdoutLog(name string, offset, length int) (*TailResult, error)
+	TailProcessStderrLog(name string, offset, length int) (*TailResult, error)
+}
+
+// Control The Client methods that change processes, logs or supervisord itself
+type Control interface {
+	ClearLog() (bool, error)
+	Shutdown() error
+	Restart() error
+	ReloadConfig() (added []string, changed []string, removed []string, err error)
+	AddProcessGroup(name string) (bool, error)
+	RemoveProcessGroup(name string) (bool, error)
+	StartProcess(name string, wait bool) error
+	StartProcessGroup(name string, wait bool) ([]ActionStatus, error)
+	StartAllProcesses(wait bool) ([]ActionStatus, error)
+	StopProcess(name string, wait bool) error
+	StopProcessGroup(name string, wait bool) ([]ActionStatus, error)
+	StopAllProcesses(wait bool) ([]ActionStatus, error)
+	SignalProcess(name string, signal syscall.Signal) error
+	SignalProcessGroup(name string, signal syscall.Signal) ([]ActionStatus, error)
+	SignalAllProcesses(signal syscall.Signal) ([]ActionStatus, error)
+	SignalProcessNamed(name string, signal Signal) error
+	SignalProcessGroupNamed(name string, signal Signal) ([]ActionStatus, error)
+	SignalAllProcessesNamed(signal Signal) ([]ActionStatus, error)
+	ClearProcessLogs(name string) error
+	ClearAllProcessLogs() ([]ActionStatus, error)
+	SendProcessStdin(name, chars string) error
+}
+
+// Interface The supervisord RPC methods of Client. Helpers built on top of them,
+// like ReadProcessLog, the Bulk variants, Attach or Twiddler, stay on *Client;
+// the components of this package accept ReadOnly or Interface instead, so
+// supervisortest.Fake can stand in for the server in tests. Call, CallMethod and
+// Multicall may read or change anything and are neither ReadOnly nor Control.
+type Interface interface {
+	ReadOnly
+	Control
+	Call(ns Namespace, method string, args []interface{}, reply interface{}) error
+	CallMethod(name string, args []interface{}, reply interface{}) error
+	Multicall(calls []MulticallCall) ([]MulticallResult, error)
+	Close() error
+}
+
+var _ Interface = (*Client)(nil)
@@ -0,0 +1,180 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Call Invoke any method of any namespace, e.g. the ones registered by an
+// rpcinterface plugin. reply must be a pointer the result can be decoded into.
+func (c *Client) Call(ns Namespace, method string, args []interface{}, reply interface{}) error {
+	return c.call(ns, method, args, reply)
+}
+
+// CallMethod Invoke a method by its full name, e.g. "twiddler.getGroupNames"
+func (c *Client) CallMethod(name string, args []interface{}, reply interface{}) error {
+	ns, method, err := SplitMethod(name)
+	if err != nil {
+		return err
+	}
+	return c.call(ns, method, args, reply)
+}
+
+// SplitMethod Split a full method name into namespace and method
+func SplitMethod(name string) (Namespace, string, error) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", fmt.Errorf("invalid method name %q", name)
+	}
+	return Namespace(name[:i]), name[i+1:], nil
+}
+
+// MethodSignature The return and parameter types of a method
+type MethodSignature struct {
+	Return string   `json:"return"`
+	Params []string `json:"params"`
+}
+
+// MethodSignature Return the signatures of the method named name. supervisord
+// answers with a single flat [return, params...] list, other servers with a
+// list of them, both are accepted.
+func (c *Client) MethodSignature(name string) ([]MethodSignature, error) {
+	result := make([]interface{}, 0)
+	args := []interface{}{name}
+	if err := c.call(SystemNamespace, "methodSignature", args, &result); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	if _, nested := result[0].([]interface{}); !nested {
+		result = []interface{}{result}
+	}
+	signatures := make([]MethodSignature, 0, len(result))
+	for _, item := range result {
+		types, ok := item.([]interface{})
+		if !ok || len(types) == 0 {
+			return nil, fmt.Errorf("invalid signature of %s: %v", name, item)
+		}
+		signature := MethodSignature{Params: make([]string, 0, len(types)-1)}
+		for i, typ := range types {
+			s, _ := typ.(string)
+			if i == 0 {
+				signature.Return = s
+			} else {
+				signature.Params = append(signature.Params, s)
+			}
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+// MethodDescription Everything the server tells about one method
+type MethodDescription struct {
+	Name       string            `json:"name"`
+	Help       string            `json:"help"`
+	Signatures []MethodSignature `json:"signatures"`
+}
+
+// Introspection A description of the methods of a server, it can be saved as
+// JSON and used without a connection, e.g. by cmd/supervisor-gen
+type Introspection struct {
+	Methods []MethodDescription `json:"methods"`
+}
+
+// Introspect Describe every method of the namespaces, or of all namespaces when
+// none is given, with ListMethods, MethodHelp and MethodSignature
+func (c *Client) Introspect(namespaces ...Namespace) (*Introspection, error) {
+	methods, err := c.ListMethods()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(methods)
+	introspection := &Introspection{Methods: make([]MethodDescription, 0, len(methods))}
+	for _, name := range methods {
+		ns, _, err := SplitMethod(name)
+		if err != nil || !containsNamespace(namespaces, ns) {
+			continue
+		}
+		help, err := c.MethodHelp(name)
+		if err != nil {
+			return nil, err
+		}
+		signatures, err := c.MethodSignature(name)
+		var fault *Fault
+		if err != nil && !(errors.As(err, &fault) && fault.Code == StatusSignatureUnsupported) {
+			return nil, err
+		}
+		introspection.Methods = append(introspection.Methods, MethodDescription{
+			Name:       name,
+			Help:       help,
+			Signatures: signatures,
+		})
+	}
+	return introspection, nil
+}
+
+func containsNamespace(namespaces []Namespace, ns Namespace) bool {
+	if len(namespaces) == 0 {
+		return true
+	}
+	for _, n := range namespaces {
+		if n == ns {
+			return true
+		}
+	}
+	return false
+}
+
+// MulticallCall One call of a system.multicall batch
+type MulticallCall struct {
+	MethodName string        `xmlrpc:"methodName"`
+	Params     []interface{} `xmlrpc:"params"`
+}
+
+// MulticallResult The outcome of one call of a batch, Err is a *Fault when the call failed
+type MulticallResult struct {
+	Value interface{}
+	Err   error
+}
+
+// Multicall Send several calls in one request with system.multicall, the
+// results are in the order of calls
+func (c *Client) Multicall(calls []MulticallCall) ([]MulticallResult, error) {
+	batch := make([]MulticallCall, len(calls))
+	for i, call := range calls {
+		batch[i] = call
+		if call.Params == nil {
+			batch[i].Params = []interface{}{}
+		}
+	}
+	result := make([]interface{}, 0, len(calls))
+	args := []interface{}{batch}
+	if err := c.call(SystemNamespace, "multicall", args, &result); err != nil {
+		return nil, err
+	}
+	if len(result) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(result), len(calls))
+	}
+	results := make([]MulticallResult, 0, len(result))
+	for _, item := range result {
+		switch v := item.(type) {
+		case []interface{}:
+			var value interface{}
+			if len(v) > 0 {
+				value = v[0]
+			}
+			results = append(results, MulticallResult{Value: value})
+		case map[string]interface{}:
+			code, _ := v["faultCode"].(int64)
+			text, _ := v["faultString"].(string)
+			results = append(results, MulticallResult{Err: &Fault{Code: Status(code), String: text}})
+		default:
+			return nil, fmt.Errorf("invalid multicall result %v", item)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package supervisor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCallPluginNamespace(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"system.listMethods": func(args []interface{}) (interface{}, error) {
+			return []string{"cache.getKeys"}, nil
+		},
+		"supervisor.getAPIVersion": func(args []interface{}) (interface{}, error) {
+			return "3.0", nil
+		},
+		"supervisor.getSupervisorVersion": func(args []interface{}) (interface{}, error) {
+			return "4.2.2", nil
+		},
+		"cache.getKeys": func(args []interface{}) (interface{}, error) {
+			return []string{"a", "b"}, nil
+		},
+	})
+	cli := ts.client(t)
+	var keys []string
+	if err := cli.CallMethod("cache.getKeys", nil, &keys); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if err := cli.Call("cache", "clear", nil, nil); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected unlisted plugin method to be unsupported but %v", err)
+	}
+}
+
+func TestMethodSignature(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"system.methodSignature": func(args []interface{}) (interface{}, error) {
+			return []string{"boolean", "string", "boolean"}, nil
+		},
+	})
+	signatures, err := ts.client(t).MethodSignature("supervisor.startProcess")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MethodSignature{{Return: "boolean", Params: []string{"string", "boolean"}}}
+	if !reflect.DeepEqual(signatures, expected) {
+		t.Fatalf("expected %+v but %+v", expected, signatures)
+	}
+}
+
+func TestMulticall(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"system.multicall": func(args []interface{}) (interface{}, error) {
+			calls := args[0].([]interface{})
+			results := make([]interface{}, 0, len(calls))
+			for _, call := range calls {
+				switch call.(map[string]interface{})["methodName"] {
+				case "supervisor.getPID":
+					results = append(results, []interface{}{42})
+				default:
+					results = append(results, map[string]interface{}{"faultCode": 10, "faultString": "BAD_NAME: nope"})
+				}
+			}
+			return results, nil
+		},
+	})
+	results, err := ts.client(t).Multicall([]MulticallCall{
+		{MethodName: "supervisor.getPID"},
+		{MethodName: "supervisor.getProcessInfo", Params: []interface{}{"nope"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || results[0].Value != int64(42) {
+		t.Fatalf("unexpected first result %+v", results[0])
+	}
+	var fault *Fault
+	if !errors.As(results[1].Err, &fault) || fault.Code != StatusBadName {
+		t.Fatalf("expected BAD_NAME fault but %+v", results[1])
+	}
+}
@@ -0,0 +1,129 @@
+package supervisor
+
+import "sync"
+
+// Priority Decides which queued call is sent first once the in-flight limit is reached
+type Priority int
+
+const (
+	PriorityLow    Priority = iota // bulk reads, e.g. log reads and tails
+	PriorityNormal                 // everything not listed in defaultPriorities
+	PriorityHigh                   // process and server control
+)
+
+// defaultPriorities Keep control calls ahead of log traffic, by full method name
+var defaultPriorities = map[string]Priority{
+	"supervisor.readLog":              PriorityLow,
+	"supervisor.readProcessStdoutLog": PriorityLow,
+	"supervisor.readProcessStderrLog": PriorityLow,
+	"supervisor.tailProcessStdoutLog": PriorityLow,
+	"supervisor.tailProcessStderrLog": PriorityLow,
+	"supervisor.startProcess":         PriorityHigh,
+	"supervisor.startProcessGroup":    PriorityHigh,
+	"supervisor.startAllProcesses":    PriorityHigh,
+	"supervisor.stopProcess":          PriorityHigh,
+	"supervisor.stopProcessGroup":     PriorityHigh,
+	"supervisor.stopAllProcesses":     PriorityHigh,
+	"supervisor.signalProcess":        PriorityHigh,
+	"supervisor.signalProcessGroup":   PriorityHigh,
+	"supervisor.signalAllProcesses":   PriorityHigh,
+	"supervisor.sendProcessStdin":     PriorityHigh,
+	"supervisor.reloadConfig":         PriorityHigh,
+	"supervisor.addProcessGroup":      PriorityHigh,
+	"supervisor.removeProcessGroup":   PriorityHigh,
+	"supervisor.shutdown":             PriorityHigh,
+	"supervisor.restart":              PriorityHigh,
+}
+
+// Option Configure a Client in New
+type Option func(*Client)
+
+// WithMaxInFlight Open n connections and send at most n requests at a time, the
+// rest queue by priority and then in arrival order. Default 1, since supervisord
+// handles one request at a time anyway.
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.limiter.max = n
+		}
+	}
+}
+
+// WithPriority Override the priority of method, e.g. "supervisor.getAllProcessInfo"
+func WithPriority(method string, priority Priority) Option {
+	return func(c *Client) {
+		c.limiter.priorities[method] = priority
+	}
+}
+
+// limiter A counting semaphore handing free slots to the highest priority waiter
+type limiter struct {
+	mu         sync.Mutex
+	max        int
+	inFlight   int
+	queues     [PriorityHigh + 1][]chan struct{}
+	priorities map[string]Priority
+}
+
+func newLimiter() *limiter {
+	l := &limiter{max: 1, priorities: make(map[string]Priority, len(defaultPriorities))}
+	for method, priority := range defaultPriorities {
+		l.priorities[method] = priority
+	}
+	return l
+}
+
+// priority Return the priority of method, PriorityNormal when unknown
+func (l *limiter) priority(method string) Priority {
+	if priority, ok := l.priorities[method]; ok {
+		return clampPriority(priority)
+	}
+	return PriorityNormal
+}
+
+func clampPriority(priority Priority) Priority {
+	switch {
+	case priority < PriorityLow:
+		return PriorityLow
+	case priority > PriorityHigh:
+		return PriorityHigh
+	}
+	return priority
+}
+
+// acquire Block until method may be sent
+func (l *limiter) acquire(method string) {
+	l.mu.Lock()
+	if l.inFlight < l.max && l.queued() == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return
+	}
+	ready := make(chan struct{})
+	priority := l.priority(method)
+	l.queues[priority] = append(l.queues[priority], ready)
+	l.mu.Unlock()
+	<-ready
+}
+
+// release Hand the slot to the next waiter or free it
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for priority := PriorityHigh; priority >= PriorityLow; priority-- {
+		if queue := l.queues[priority]; len(queue) > 0 {
+			l.queues[priority] = queue[1:]
+			close(queue[0])
+			return
+		}
+	}
+	l.inFlight--
+}
+
+func (l *limiter) queued() int {
+	n := 0
+	for _, queue := range l.queues {
+		n += len(queue)
+	}
+	return n
+}
@@ -0,0 +1,185 @@
+package supervisor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientConcurrentUse(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			return map[string]interface{}{"name": args[0], "group": args[0], "statename": "RUNNING", "state": 20}, nil
+		},
+	})
+	cli := ts.client(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("web_%d", i)
+			info, err := cli.GetProcessInfo(name)
+			if err == nil && info.Name != name {
+				err = fmt.Errorf("asked for %s, got %s", name, info.Name)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClientMaxInFlight(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getState": func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return map[string]interface{}{"statecode": 1, "statename": "RUNNING"}, nil
+		},
+	})
+	cli, err := New(ts.URL, nil, WithMaxInFlight(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cli.GetState(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak != 2 {
+		t.Fatalf("peak in flight %d, want 2", peak)
+	}
+}
+
+func TestClientPriorityOrder(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	order := make([]string, 0)
+	record := func(method string) testHandler {
+		return func(args []interface{}) (interface{}, error) {
+			mu.Lock()
+			order = append(order, method)
+			mu.Unlock()
+			return true, nil
+		}
+	}
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getState": func(args []interface{}) (interface{}, error) {
+			close(entered)
+			<-release
+			return map[string]interface{}{"statecode": 1, "statename": "RUNNING"}, nil
+		},
+		"supervisor.readProcessStdoutLog": record("read"),
+		"supervisor.stopProcess":          record("stop"),
+	})
+	cli := ts.client(t)
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	t.Cleanup(unblock)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		cli.GetState()
+	}()
+	<-entered
+	go func() {
+		defer wg.Done()
+		cli.ReadProcessStdoutLog("web", 0, 10)
+	}()
+	waitQueued(t, cli.limiter, 1)
+	go func() {
+		defer wg.Done()
+		cli.StopProcess("web", true)
+	}()
+	waitQueued(t, cli.limiter, 2)
+	unblock()
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != "stop" {
+		t.Fatalf("served %v, want stop before read", order)
+	}
+}
+
+func TestLimiterPriority(t *testing.T) {
+	l := newLimiter()
+	l.max = 1
+	l.priorities["custom.method"] = PriorityHigh
+	l.acquire("supervisor.getState")
+
+	order := make(chan string, 3)
+	var wg sync.WaitGroup
+	queued := 0
+	queue := func(method string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.acquire(method)
+			order <- method
+			l.release()
+		}()
+		queued++
+		waitQueued(t, l, queued)
+	}
+	queue("supervisor.readProcessStdoutLog")
+	queue("supervisor.getAllProcessInfo")
+	queue("supervisor.stopProcess")
+	l.release()
+
+	want := []string{"supervisor.stopProcess", "supervisor.getAllProcessInfo", "supervisor.readProcessStdoutLog"}
+	for _, method := range want {
+		if got := <-order; got != method {
+			t.Fatalf("got %s, want %s", got, method)
+		}
+	}
+	wg.Wait()
+	if l.inFlight != 0 {
+		t.Fatalf("in flight %d after all releases", l.inFlight)
+	}
+	if l.priority("custom.method") != PriorityHigh || l.priority("unknown") != PriorityNormal {
+		t.Fatal("unexpected priorities")
+	}
+}
+
+// waitQueued Wait until n callers queued in l
+func waitQueued(t *testing.T, l *limiter, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		l.mu.Lock()
+		queued := l.queued()
+		l.mu.Unlock()
+		if queued >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("caller did not queue")
+}
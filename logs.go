@@ -0,0 +1,54 @@
+package supervisor
+
+import "fmt"
+
+// LogStream The stdout or stderr log of a process
+type LogStream string
+
+const (
+	StdoutStream LogStream = "stdout"
+	StderrStream LogStream = "stderr"
+)
+
+// ReadProcessLog Read length bytes from name's stream log starting at offset
+func (c *Client) ReadProcessLog(name string, stream LogStream, offset, length int) (string, error) {
+	return readProcessLog(c, name, stream, offset, length)
+}
+
+// TailProcessLog Tail name's stream log, see TailProcessStdoutLog
+func (c *Client) TailProcessLog(name string, stream LogStream, offset, length int) (*TailResult, error) {
+	return tailProcessLog(c, name, stream, offset, length)
+}
+
+// ProcessLogSize Return the current size in bytes of name's stream log
+func (c *Client) ProcessLogSize(name string, stream LogStream) (int64, error) {
+	return processLogSize(c, name, stream)
+}
+
+func readProcessLog(client ReadOnly, name string, stream LogStream, offset, length int) (string, error) {
+	switch stream {
+	case StdoutStream, "":
+		return client.ReadProcessStdoutLog(name, offset, length)
+	case StderrStream:
+		return client.ReadProcessStderrLog(name, offset, length)
+	}
+	return "", fmt.Errorf("unknown log stream %q", stream)
+}
+
+func tailProcessLog(client ReadOnly, name string, stream LogStream, offset, length int) (*TailResult, error) {
+	switch stream {
+	case StdoutStream, "":
+		return client.TailProcessStdoutLog(name, offset, length)
+	case StderrStream:
+		return client.TailProcessStderrLog(name, offset, length)
+	}
+	return nil, fmt.Errorf("unknown log stream %q", stream)
+}
+
+func processLogSize(client ReadOnly, name string, stream LogStream) (int64, error) {
+	tail, err := tailProcessLog(client, name, stream, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	return tail.Offset, nil
+}
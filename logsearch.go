@@ -0,0 +1,201 @@
+package supervisor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SearchOptions Tune a log search, zero values use the defaults
+type SearchOptions struct {
+	Stream     LogStream // default stdout
+	Backward   bool      // search from the end of the log, newest match first
+	Context    int       // lines of context before and after every match
+	MaxMatches int       // stop after this many matches, 0 means no limit
+	ChunkSize  int       // max bytes per read, default 64KB
+	Offset     int64     // forward: where to start, backward: where to end, 0 means end of log
+}
+
+// LogMatch A log line matching the pattern
+type LogMatch struct {
+	Offset int64    // byte offset of the start of the line
+	Line   string   // without the newline
+	Before []string // context lines before the match, in log order
+	After  []string // context lines after the match, in log order
+}
+
+type logLine struct {
+	offset int64
+	text   string
+}
+
+// SearchProcessLog Scan name's log for lines matching pattern with bounded
+// ReadProcessStdoutLog/ReadProcessStderrLog calls. Lines spanning chunk
+// boundaries are joined before matching. The log size is taken when the search
+// starts, data written afterwards is not searched.
+func (c *Client) SearchProcessLog(name string, pattern *regexp.Regexp, opts SearchOptions) ([]LogMatch, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 64 * 1024
+	}
+	size, err := c.ProcessLogSize(name, opts.Stream)
+	if err != nil {
+		return nil, err
+	}
+	s := &lineScanner{pattern: pattern, context: opts.Context, max: opts.MaxMatches}
+	if opts.Backward {
+		end := size
+		if opts.Offset > 0 && opts.Offset < size {
+			end = opts.Offset
+		}
+		err = c.scanBackward(name, opts, end, s)
+	} else {
+		err = c.scanForward(name, opts, size, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.result(opts.Backward), nil
+}
+
+func (c *Client) scanForward(name string, opts SearchOptions, size int64, s *lineScanner) error {
+	offset := opts.Offset
+	carry, carryOffset := "", offset
+	for offset < size {
+		length := int64(opts.ChunkSize)
+		if size-offset < length {
+			length = size - offset
+		}
+		content, err := c.ReadProcessLog(name, opts.Stream, int(offset), int(length))
+		if err != nil {
+			return err
+		}
+		if content == "" {
+			break
+		}
+		offset += int64(len(content))
+		data := carry + content
+		end := strings.LastIndexByte(data, '\n') + 1
+		for _, line := range splitLogLines(data[:end], carryOffset) {
+			if s.add(line) {
+				return nil
+			}
+		}
+		carry, carryOffset = data[end:], carryOffset+int64(end)
+	}
+	if carry != "" {
+		s.add(logLine{offset: carryOffset, text: carry})
+	}
+	return nil
+}
+
+func (c *Client) scanBackward(name string, opts SearchOptions, end int64, s *lineScanner) error {
+	hi := end
+	carry := "" // the start of a line beginning before hi, newline included
+	for hi > 0 {
+		lo := hi - int64(opts.ChunkSize)
+		if lo < 0 {
+			lo = 0
+		}
+		content, err := c.ReadProcessLog(name, opts.Stream, int(lo), int(hi-lo))
+		if err != nil {
+			return err
+		}
+		data := content + carry
+		start := 0
+		if lo > 0 {
+			i := strings.IndexByte(data, '\n')
+			if i < 0 {
+				carry, hi = data, lo
+				continue
+			}
+			start = i + 1
+		}
+		lines := splitLogLines(data[start:], lo+int64(start))
+		for i := len(lines) - 1; i >= 0; i-- {
+			if s.add(lines[i]) {
+				return nil
+			}
+		}
+		carry, hi = data[:start], lo
+	}
+	return nil
+}
+
+// splitLogLines Split data starting at offset into lines without newlines
+func splitLogLines(data string, offset int64) []logLine {
+	lines := make([]logLine, 0)
+	for len(data) > 0 {
+		i := strings.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, logLine{offset: offset, text: data})
+			break
+		}
+		lines = append(lines, logLine{offset: offset, text: data[:i]})
+		offset += int64(i + 1)
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// lineScanner Match lines in processing order and collect context, "leading"
+// lines were processed before a match and "trailing" ones after it
+type lineScanner struct {
+	pattern  *regexp.Regexp
+	context  int
+	max      int
+	recent   []string
+	matches  []LogMatch
+	trailing []int // remaining trailing lines wanted per match
+}
+
+// add Process the next line and report whether the search is complete
+func (s *lineScanner) add(line logLine) bool {
+	for i, wanted := range s.trailing {
+		if wanted > 0 {
+			s.matches[i].After = append(s.matches[i].After, line.text)
+			s.trailing[i]--
+		}
+	}
+	if (s.max <= 0 || len(s.matches) < s.max) && s.pattern.MatchString(line.text) {
+		s.matches = append(s.matches, LogMatch{
+			Offset: line.offset,
+			Line:   line.text,
+			Before: append([]string(nil), s.recent...),
+		})
+		s.trailing = append(s.trailing, s.context)
+	}
+	if s.context > 0 {
+		s.recent = append(s.recent, line.text)
+		if len(s.recent) > s.context {
+			s.recent = s.recent[1:]
+		}
+	}
+	if s.max <= 0 || len(s.matches) < s.max {
+		return false
+	}
+	for _, wanted := range s.trailing {
+		if wanted > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// result Return the matches with context in log order
+func (s *lineScanner) result(backward bool) []LogMatch {
+	if !backward {
+		return s.matches
+	}
+	for i := range s.matches {
+		m := &s.matches[i]
+		m.Before, m.After = reverseLines(m.After), reverseLines(m.Before)
+	}
+	return s.matches
+}
+
+func reverseLines(lines []string) []string {
+	reversed := make([]string, len(lines))
+	for i, line := range lines {
+		reversed[len(lines)-1-i] = line
+	}
+	return reversed
+}
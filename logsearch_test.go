@@ -0,0 +1,68 @@
+package supervisor
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func newLogSearchServer(t *testing.T, log string) *testServer {
+	return newTestServer(t, map[string]testHandler{
+		"supervisor.tailProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", len(log), false}, nil
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			offset, length := int(args[1].(int64)), int(args[2].(int64))
+			if offset >= len(log) {
+				return "", nil
+			}
+			end := offset + length
+			if length == 0 || end > len(log) {
+				end = len(log)
+			}
+			return log[offset:end], nil
+		},
+	})
+}
+
+func TestSearchProcessLog(t *testing.T) {
+	lines := []string{"boot", "ready", "request a", "error: disk full", "request b", "request c", "error: timeout", "bye"}
+	log := strings.Join(lines, "\n")
+	client := newLogSearchServer(t, log).client(t)
+	pattern := regexp.MustCompile(`^error: `)
+
+	// a chunk size smaller than a line makes every match span chunks
+	matches, err := client.SearchProcessLog("web", pattern, SearchOptions{ChunkSize: 5, Context: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches but %+v", matches)
+	}
+	first := matches[0]
+	if first.Line != "error: disk full" || first.Offset != int64(strings.Index(log, "error: disk")) {
+		t.Fatalf("unexpected first match %+v", first)
+	}
+	if len(first.Before) != 1 || first.Before[0] != "request a" || len(first.After) != 1 || first.After[0] != "request b" {
+		t.Fatalf("unexpected context %+v", first)
+	}
+
+	matches, err = client.SearchProcessLog("web", pattern, SearchOptions{ChunkSize: 7, Context: 2, Backward: true, MaxMatches: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Line != "error: timeout" || matches[0].Offset != int64(strings.Index(log, "error: timeout")) {
+		t.Fatalf("expected the newest match but %+v", matches)
+	}
+	if strings.Join(matches[0].Before, ",") != "request b,request c" || strings.Join(matches[0].After, ",") != "bye" {
+		t.Fatalf("unexpected backward context %+v", matches[0])
+	}
+
+	matches, err = client.SearchProcessLog("web", regexp.MustCompile(`^b`), SearchOptions{ChunkSize: 3, Backward: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].Line != "bye" || matches[1].Line != "boot" || matches[1].Offset != 0 {
+		t.Fatalf("expected both ends of the log but %+v", matches)
+	}
+}
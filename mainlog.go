@@ -0,0 +1,198 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MainLogEvent The kind of a supervisord main log entry
+type MainLogEvent string
+
+const (
+	EventSpawned  MainLogEvent = "spawned"  // spawned: 'web' with pid 123
+	EventSpawnErr MainLogEvent = "spawnerr" // spawnerr: can't find command 'foo'
+	EventSuccess  MainLogEvent = "success"  // success: web entered RUNNING state, ...
+	EventExited   MainLogEvent = "exited"   // exited: web (exit status 1; not expected)
+	EventStopped  MainLogEvent = "stopped"  // stopped: web (terminated by SIGTERM)
+	EventGaveUp   MainLogEvent = "gave_up"  // gave up: web entered FATAL state, ...
+	EventWaiting  MainLogEvent = "waiting"  // waiting for web to stop
+	EventStarted  MainLogEvent = "started"  // supervisord started with pid 1
+	EventOther    MainLogEvent = "other"
+)
+
+// MainLogEntry One parsed line of the supervisord main log
+type MainLogEntry struct {
+	Time       time.Time
+	Level      string // CRIT, ERRO, WARN, INFO, DEBG, TRAC or BLAT
+	Event      MainLogEvent
+	Process    string // process name, empty when the line is not about a process
+	Pid        int
+	ExitStatus int    // -1 when terminated by a signal
+	Signal     string // e.g. SIGTERM when terminated by a signal
+	Expected   bool   // exit status listed in exitcodes, only for EventExited
+	Message    string // the text after the level, continuation lines included
+}
+
+const mainLogTimeLayout = "2006-01-02 15:04:05,000"
+
+var (
+	mainLogLinePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}) ([A-Z]+) (.*)$`)
+	spawnedPattern     = regexp.MustCompile(`^spawned: '(.+)' with pid (\d+)`)
+	spawnErrPattern    = regexp.MustCompile(`^spawnerr: `)
+	successPattern     = regexp.MustCompile(`^success: (\S+) entered RUNNING state`)
+	exitedPattern      = regexp.MustCompile(`^(exited|stopped): (\S+) \((?:exit status (\d+)|terminated by ([A-Z0-9]+)).*?(?:; (not expected|expected))?\)$`)
+	gaveUpPattern      = regexp.MustCompile(`^gave up: (\S+) entered FATAL state`)
+	waitingPattern     = regexp.MustCompile(`^waiting for (\S+) to (?:stop|die)`)
+	startedPattern     = regexp.MustCompile(`^supervisord started with pid (\d+)`)
+)
+
+// ParseMainLogLine Parse one line of the supervisord main log, timestamps are
+// read in the local time zone as supervisord writes them
+func ParseMainLogLine(line string) (MainLogEntry, error) {
+	m := mainLogLinePattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return MainLogEntry{}, fmt.Errorf("invalid main log line %q", line)
+	}
+	ts, err := time.ParseInLocation(mainLogTimeLayout, m[1], time.Local)
+	if err != nil {
+		return MainLogEntry{}, err
+	}
+	entry := MainLogEntry{Time: ts, Level: m[2], Event: EventOther, Message: m[3]}
+	msg := m[3]
+	if m := spawnedPattern.FindStringSubmatch(msg); m != nil {
+		entry.Event, entry.Process = EventSpawned, m[1]
+		entry.Pid, _ = strconv.Atoi(m[2])
+	} else if spawnErrPattern.MatchString(msg) {
+		entry.Event = EventSpawnErr
+	} else if m := successPattern.FindStringSubmatch(msg); m != nil {
+		entry.Event, entry.Process = EventSuccess, m[1]
+	} else if m := exitedPattern.FindStringSubmatch(msg); m != nil {
+		entry.Event, entry.Process = EventExited, m[2]
+		if m[1] == "stopped" {
+			entry.Event = EventStopped
+		}
+		if m[3] != "" {
+			entry.ExitStatus, _ = strconv.Atoi(m[3])
+		} else {
+			entry.ExitStatus, entry.Signal = -1, m[4]
+		}
+		entry.Expected = m[5] == "expected"
+	} else if m := gaveUpPattern.FindStringSubmatch(msg); m != nil {
+		entry.Event, entry.Process = EventGaveUp, m[1]
+	} else if m := waitingPattern.FindStringSubmatch(msg); m != nil {
+		entry.Event, entry.Process = EventWaiting, m[1]
+	} else if m := startedPattern.FindStringSubmatch(msg); m != nil {
+		entry.Event = EventStarted
+		entry.Pid, _ = strconv.Atoi(m[1])
+	}
+	return entry, nil
+}
+
+// ParseMainLog Parse main log content, lines without a timestamp are appended
+// to the message of the previous entry
+func ParseMainLog(content string) []MainLogEntry {
+	entries := make([]MainLogEntry, 0)
+	for _, line := range strings.Split(content, "\n") {
+		if line == "" {
+			continue
+		}
+		entry, err := ParseMainLogLine(line)
+		if err != nil {
+			if n := len(entries); n > 0 {
+				entries[n-1].Message += "\n" + line
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ExitHistory Return the exited and stopped entries of process, oldest first
+func ExitHistory(entries []MainLogEntry, process string) []MainLogEntry {
+	exits := make([]MainLogEntry, 0)
+	for _, entry := range entries {
+		if entry.Process == process && (entry.Event == EventExited || entry.Event == EventStopped) {
+			exits = append(exits, entry)
+		}
+	}
+	return exits
+}
+
+// MainLogFollower Read new main log entries with ReadLog, remembering the offset
+type MainLogFollower struct {
+	client    ReadOnly
+	Offset    int // byte offset of the next read
+	ChunkSize int // max bytes per ReadLog call, default 64KB
+	partial   string
+}
+
+// NewMainLogFollower Create a follower starting at offset, 0 reads the whole log
+func NewMainLogFollower(client ReadOnly, offset int) *MainLogFollower {
+	return &MainLogFollower{client: client, Offset: offset, ChunkSize: 64 * 1024}
+}
+
+// Poll Read everything written since the previous poll and return the complete
+// lines as entries. A log that shrank below the offset was rotated or cleared,
+// reading then starts again at 0.
+func (f *MainLogFollower) Poll() ([]MainLogEntry, error) {
+	var b strings.Builder
+	for {
+		content, err := f.client.ReadLog(f.Offset, f.ChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if content == "" && b.Len() == 0 && f.Offset > 0 {
+			rotated, err := f.rotated()
+			if err != nil {
+				return nil, err
+			}
+			if rotated {
+				f.Offset, f.partial = 0, ""
+				continue
+			}
+		}
+		f.Offset += len(content)
+		b.WriteString(content)
+		if len(content) < f.ChunkSize {
+			break
+		}
+	}
+	data := f.partial + b.String()
+	end := strings.LastIndexByte(data, '\n')
+	f.partial = data[end+1:]
+	return ParseMainLog(data[:end+1]), nil
+}
+
+// rotated Report whether the log is now shorter than the offset
+func (f *MainLogFollower) rotated() (bool, error) {
+	last, err := f.client.ReadLog(f.Offset-1, 1)
+	if err != nil {
+		return false, err
+	}
+	return last == "", nil
+}
+
+// Follow Poll every interval and pass new entries to handle until ctx is done
+func (f *MainLogFollower) Follow(ctx context.Context, interval time.Duration, handle func(MainLogEntry)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		entries, err := f.Poll()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			handle(entry)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package supervisor
+
+import (
+	"testing"
+)
+
+const testMainLog = `2021-01-20 10:11:12,345 INFO supervisord started with pid 1
+2021-01-20 10:11:13,001 INFO spawned: 'web' with pid 123
+2021-01-20 10:11:14,002 INFO success: web entered RUNNING state, process has stayed up for > than 1 seconds (startsecs)
+2021-01-20 10:12:00,000 INFO exited: web (exit status 1; not expected)
+2021-01-20 10:12:01,000 INFO spawnerr: can't find command 'nope'
+2021-01-20 10:12:02,000 INFO gave up: worker entered FATAL state, too many start retries too quickly
+2021-01-20 10:13:00,000 INFO waiting for web to stop
+2021-01-20 10:13:01,000 INFO stopped: web (terminated by SIGTERM)
+2021-01-20 10:13:02,000 CRIT uncaptured python exception
+Traceback (most recent call last):
+2021-01-20 10:14:00,000 INFO exited: web (terminated by SIGSEGV (core dumped); not expected)
+2021-01-20 10:14:01,000 INFO exited: cron (terminated by SIGKILL (core dumped); expected)
+`
+
+func TestParseMainLog(t *testing.T) {
+	entries := ParseMainLog(testMainLog)
+	if len(entries) != 11 {
+		t.Fatalf("expected 11 entries but %d", len(entries))
+	}
+	spawned := entries[1]
+	if spawned.Event != EventSpawned || spawned.Process != "web" || spawned.Pid != 123 || spawned.Time.Minute() != 11 {
+		t.Fatalf("unexpected spawned entry %+v", spawned)
+	}
+	exited := entries[3]
+	if exited.Event != EventExited || exited.ExitStatus != 1 || exited.Expected {
+		t.Fatalf("unexpected exited entry %+v", exited)
+	}
+	dumped := entries[9]
+	if dumped.Event != EventExited || dumped.Signal != "SIGSEGV" || dumped.Expected {
+		t.Fatalf("unexpected core dumped entry %+v", dumped)
+	}
+	if !entries[10].Expected || entries[10].Process != "cron" {
+		t.Fatalf("unexpected expected core dumped entry %+v", entries[10])
+	}
+	stopped := entries[7]
+	if stopped.Event != EventStopped || stopped.Signal != "SIGTERM" || stopped.ExitStatus != -1 {
+		t.Fatalf("unexpected stopped entry %+v", stopped)
+	}
+	if entries[5].Event != EventGaveUp || entries[5].Process != "worker" || entries[4].Event != EventSpawnErr {
+		t.Fatalf("unexpected entries %+v %+v", entries[4], entries[5])
+	}
+	if entries[8].Level != "CRIT" || entries[8].Message != "uncaptured python exception\nTraceback (most recent call last):" {
+		t.Fatalf("continuation line not attached: %q", entries[8].Message)
+	}
+	if history := ExitHistory(entries, "web"); len(history) != 3 {
+		t.Fatalf("expected 3 exits of web but %d", len(history))
+	}
+}
+
+func TestMainLogFollower(t *testing.T) {
+	log := testMainLog[:100]
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.readLog": func(args []interface{}) (interface{}, error) {
+			offset, length := int(args[0].(int64)), int(args[1].(int64))
+			if offset >= len(log) {
+				return "", nil
+			}
+			end := offset + length
+			if length == 0 || end > len(log) {
+				end = len(log)
+			}
+			return log[offset:end], nil
+		},
+	})
+	follower := NewMainLogFollower(ts.client(t), 0)
+	follower.ChunkSize = 16
+	entries, err := follower.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Event != EventStarted {
+		t.Fatalf("expected only the complete first line but %+v", entries)
+	}
+	log = testMainLog
+	entries, err = follower.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 10 || entries[0].Event != EventSpawned {
+		t.Fatalf("expected the remaining entries but %d", len(entries))
+	}
+	// cleared log starts over
+	log = "2021-01-20 11:00:00,000 INFO spawned: 'web' with pid 200\n"
+	entries, err = follower.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Pid != 200 {
+		t.Fatalf("expected entry after rotation but %+v", entries)
+	}
+}
@@ -0,0 +1,412 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert A notification about a process going FATAL or exiting unexpectedly
+type Alert struct {
+	Hostname   string    `json:"hostname"`
+	Name       string    `json:"name"` // group:name
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Pid        int       `json:"pid"`
+	ExitStatus int       `json:"exit_status"`
+	SpawnErr   string    `json:"spawn_err,omitempty"`
+	StderrTail []string  `json:"stderr_tail,omitempty"`
+	Suppressed int       `json:"suppressed,omitempty"` // alerts dropped by the rate limit since the previous one
+	Time       time.Time `json:"time"`
+}
+
+// Subject Return a one line summary of the alert
+func (a Alert) Subject() string {
+	if a.To == "EXITED" {
+		return fmt.Sprintf("%s: %s exited unexpectedly (exit status %d)", a.Hostname, a.Name, a.ExitStatus)
+	}
+	return fmt.Sprintf("%s: %s %s -> %s", a.Hostname, a.Name, a.From, a.To)
+}
+
+// Body Return a plain text description of the alert
+func (a Alert) Body() string {
+	b := strings.Builder{}
+	b.WriteString(a.Subject() + "\n\n")
+	b.WriteString(fmt.Sprintf("host: %s\nprocess: %s\nstate: %s -> %s\npid: %d\nexit status: %d\ntime: %s\n",
+		a.Hostname, a.Name, a.From, a.To, a.Pid, a.ExitStatus, a.Time.Format(time.RFC3339)))
+	if a.SpawnErr != "" {
+		b.WriteString("spawn error: " + a.SpawnErr + "\n")
+	}
+	if a.Suppressed > 0 {
+		b.WriteString(fmt.Sprintf("suppressed alerts: %d\n", a.Suppressed))
+	}
+	if len(a.StderrTail) > 0 {
+		b.WriteString("\nstderr:\n" + strings.Join(a.StderrTail, "\n") + "\n")
+	}
+	return b.String()
+}
+
+// Sink Deliver alerts somewhere
+type Sink interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+// NotifierConfig Tune alerting, zero values use the defaults
+type NotifierConfig struct {
+	Hostname     string        // reported host, default os.Hostname
+	DedupWindow  time.Duration // identical alerts within the window are dropped, default 10 minutes
+	RateLimit    int           // max alerts per RateInterval, default 10
+	RateInterval time.Duration // default 1 minute
+	StderrBytes  int           // bytes of stderr log fetched per alert, default 2048
+	StderrLines  int           // last stderr lines attached to an alert, default 10
+}
+
+// Notifier Turn process transitions into deduplicated, rate limited alerts
+type Notifier struct {
+	client     ReadOnly
+	config     NotifierConfig
+	sinks      []Sink
+	watcher    *Watcher
+	exitCodes  map[string][]int
+	sent       map[string]time.Time
+	recent     []time.Time
+	suppressed int
+	now        func() time.Time
+}
+
+// NewNotifier Create a notifier delivering alerts to sinks
+func NewNotifier(client ReadOnly, config NotifierConfig, sinks ...Sink) *Notifier {
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = 10 * time.Minute
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = 10
+	}
+	if config.RateInterval <= 0 {
+		config.RateInterval = time.Minute
+	}
+	if config.StderrBytes <= 0 {
+		config.StderrBytes = 2048
+	}
+	if config.StderrLines <= 0 {
+		config.StderrLines = 10
+	}
+	return &Notifier{
+		client:  client,
+		config:  config,
+		sinks:   sinks,
+		watcher: NewWatcher(client),
+		sent:    make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Poll Poll process info once and send alerts for the transitions found
+func (n *Notifier) Poll(ctx context.Context) error {
+	transitions, err := n.watcher.Poll()
+	if err != nil {
+		return err
+	}
+	return n.Handle(ctx, transitions)
+}
+
+// Run Poll every interval until ctx is done, poll and sink errors are passed to
+// onError and do not stop the loop
+func (n *Notifier) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := n.Poll(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Handle Send alerts for transitions obtained elsewhere, every sink is tried and
+// the first sink error is returned
+func (n *Notifier) Handle(ctx context.Context, transitions []Transition) error {
+	var firstErr error
+	for _, t := range transitions {
+		if t.Added || t.Removed {
+			// reloaded config, fetch the exitcodes again
+			n.exitCodes = nil
+		}
+		alert, ok := n.alert(t)
+		if !ok || !n.admit(&alert) {
+			continue
+		}
+		n.attachStderr(&alert)
+		for _, sink := range n.sinks {
+			if err := sink.Send(ctx, alert); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("notify %s: %w", alert.Name, err)
+			}
+		}
+	}
+	return firstErr
+}
+
+func (n *Notifier) alert(t Transition) (Alert, bool) {
+	if t.Added || t.Removed {
+		return Alert{}, false
+	}
+	var expected bool
+	switch {
+	case t.To.State == ProcessFatal && t.From.State != ProcessFatal:
+	case t.To.State == ProcessExited && (t.From.State != ProcessExited || t.Restarted()):
+		expected = n.expected(t.To)
+	case t.Restarted() && t.From.State == ProcessRunning && t.To.State == ProcessRunning:
+		// exited and was autorestarted between two polls
+		expected = n.expected(t.To)
+	default:
+		return Alert{}, false
+	}
+	if expected {
+		return Alert{}, false
+	}
+	alert := Alert{
+		Hostname:   n.config.Hostname,
+		Name:       t.Name,
+		From:       t.From.StateName,
+		To:         t.To.StateName,
+		Pid:        t.From.Pid,
+		ExitStatus: t.To.ExitStatus,
+		SpawnErr:   t.To.SpawnErr,
+		Time:       n.now(),
+	}
+	if t.To.Pid != 0 {
+		alert.Pid = t.To.Pid
+	}
+	return alert, true
+}
+
+func (n *Notifier) attachStderr(alert *Alert) {
+	tail, err := n.client.TailProcessStderrLog(alert.Name, 0, n.config.StderrBytes)
+	if err != nil {
+		return
+	}
+	content := tail.Content
+	if i := strings.IndexByte(content, '\n'); tail.Overflow && i >= 0 {
+		content = content[i+1:]
+	}
+	alert.StderrTail = lastLines(content, n.config.StderrLines)
+}
+
+// expected Report whether the exit status is listed in the program's exitcodes
+func (n *Notifier) expected(info ProcessInfo) bool {
+	name := info.FullName()
+	if _, ok := n.exitCodes[name]; !ok {
+		if configs, err := n.client.GetAllConfigInfo(); err == nil {
+			n.exitCodes = make(map[string][]int, len(configs))
+			for _, cfg := range configs {
+				n.exitCodes[cfg.Group+":"+cfg.Name] = cfg.ExitCodes
+			}
+		}
+	}
+	codes, ok := n.exitCodes[name]
+	if !ok {
+		codes = []int{0}
+	}
+	for _, code := range codes {
+		if code == info.ExitStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// admit Apply deduplication and the rate limit
+func (n *Notifier) admit(alert *Alert) bool {
+	now := alert.Time
+	key := alert.Name + "|" + alert.To + "|" + strconv.Itoa(alert.ExitStatus)
+	if last, ok := n.sent[key]; ok && now.Sub(last) < n.config.DedupWindow {
+		return false
+	}
+	for k, last := range n.sent {
+		if now.Sub(last) >= n.config.DedupWindow {
+			delete(n.sent, k)
+		}
+	}
+	i := 0
+	for i < len(n.recent) && now.Sub(n.recent[i]) >= n.config.RateInterval {
+		i++
+	}
+	n.recent = n.recent[i:]
+	if len(n.recent) >= n.config.RateLimit {
+		n.suppressed++
+		return false
+	}
+	n.recent = append(n.recent, now)
+	n.sent[key] = now
+	alert.Suppressed, n.suppressed = n.suppressed, 0
+	return true
+}
+
+// WebhookSink POST every alert as JSON to URL
+type WebhookSink struct {
+	URL    string
+	Header http.Header
+	Client *http.Client // nil means http.DefaultClient
+}
+
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: bad status code %d", s.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPSink Mail every alert through the SMTP server at Addr
+type SMTPSink struct {
+	Addr string // host:port
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+func (s *SMTPSink) Send(ctx context.Context, alert Alert) error {
+	msg := strings.Builder{}
+	msg.WriteString("From: " + s.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(s.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + alert.Subject() + "\r\n")
+	msg.WriteString("Date: " + alert.Time.Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(alert.Body(), "\n", "\r\n"))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// only ctx ends the conversation, so an i/o timeout always means ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if err := s.send(conn, []byte(msg.String())); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// send Do what smtp.SendMail does over an established conn
+func (s *SMTPSink) send(conn net.Conn, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// CommandSink Run a local command for every alert, the alert is written to its
+// stdin as JSON and exported in SUPERVISOR_ALERT_* environment variables
+type CommandSink struct {
+	Path string
+	Args []string
+}
+
+func (s *CommandSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, s.Path, s.Args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"SUPERVISOR_ALERT_HOSTNAME="+alert.Hostname,
+		"SUPERVISOR_ALERT_NAME="+alert.Name,
+		"SUPERVISOR_ALERT_FROM="+alert.From,
+		"SUPERVISOR_ALERT_TO="+alert.To,
+		"SUPERVISOR_ALERT_PID="+strconv.Itoa(alert.Pid),
+		"SUPERVISOR_ALERT_EXIT_STATUS="+strconv.Itoa(alert.ExitStatus),
+		"SUPERVISOR_ALERT_SPAWN_ERR="+alert.SpawnErr,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", s.Path, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
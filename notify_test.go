@@ -0,0 +1,208 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	alerts []Alert
+}
+
+func (s *recordingSink) Send(ctx context.Context, alert Alert) error {
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func TestNotifier(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllConfigInfo": func(args []interface{}) (interface{}, error) {
+			return []ProgramConfig{{Name: "web", Group: "web", ExitCodes: []int{0, 2}}}, nil
+		},
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"boom\n", 5, false}, nil
+		},
+	})
+	sink := &recordingSink{}
+	notifier := NewNotifier(ts.client(t), NotifierConfig{Hostname: "host1", RateLimit: 2}, sink)
+	now := time.Unix(5000, 0)
+	notifier.now = func() time.Time { return now }
+	running := ProcessInfo{Name: "web", Group: "web", State: ProcessRunning, StateName: "RUNNING", Pid: 10, Start: 1}
+	exited := func(status int) ProcessInfo {
+		return ProcessInfo{Name: "web", Group: "web", State: ProcessExited, StateName: "EXITED", ExitStatus: status, Start: 1}
+	}
+	fatal := ProcessInfo{Name: "web", Group: "web", State: ProcessFatal, StateName: "FATAL", SpawnErr: "can't find command", Start: 2}
+	ctx := context.Background()
+
+	// expected exit code
+	if err := notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited(2)}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.alerts) != 0 {
+		t.Fatalf("alerted on expected exit: %+v", sink.alerts)
+	}
+	// unexpected exit, then the same one again is deduplicated
+	for i := 0; i < 2; i++ {
+		if err := notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited(1)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sink.alerts) != 1 {
+		t.Fatalf("expected one deduplicated alert but %d", len(sink.alerts))
+	}
+	alert := sink.alerts[0]
+	if alert.Hostname != "host1" || alert.ExitStatus != 1 || alert.Pid != 10 || len(alert.StderrTail) != 1 {
+		t.Fatalf("unexpected alert %+v", alert)
+	}
+	// rate limit allows 2 alerts per minute
+	notifier.Handle(ctx, []Transition{{Name: "web:web", From: exited(1), To: fatal}})
+	notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited(3)}})
+	if len(sink.alerts) != 2 {
+		t.Fatalf("expected rate limited alerts but %d", len(sink.alerts))
+	}
+	now = now.Add(2 * time.Minute)
+	notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited(4)}})
+	if len(sink.alerts) != 3 || sink.alerts[2].Suppressed != 1 {
+		t.Fatalf("expected suppressed count on next alert but %+v", sink.alerts)
+	}
+	if sink.alerts[1].SpawnErr != "can't find command" {
+		t.Fatalf("expected spawn error in alert but %+v", sink.alerts[1])
+	}
+	// deduplication entries expire with the window
+	now = now.Add(15 * time.Minute)
+	notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited(5)}})
+	if len(notifier.sent) != 1 {
+		t.Fatalf("expected expired dedup entries dropped but %v", notifier.sent)
+	}
+}
+
+func TestSMTPSinkContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// accept and never greet
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+	sink := &SMTPSink{Addr: ln.Addr().String(), From: "a@example.com", To: []string{"b@example.com"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() { errc <- sink.Send(ctx, Alert{Name: "web:web"}) }()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded but %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send ignored the context")
+	}
+}
+
+func TestNotifierRunSurvivesPollErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	polls := 0
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			polls++
+			if polls == 1 {
+				return nil, testFault{StatusFailed, "FAILED"}
+			}
+			if polls == 3 {
+				cancel()
+			}
+			return []ProcessInfo{{Name: "web", Group: "web", State: ProcessRunning}}, nil
+		},
+	})
+	notifier := NewNotifier(ts.client(t), NotifierConfig{})
+	errs := 0
+	err := notifier.Run(ctx, time.Millisecond, func(err error) {
+		errs++
+	})
+	if !errors.Is(err, context.Canceled) || errs != 1 {
+		t.Fatalf("expected one reported poll error and the loop to go on but %d errors, %v", errs, err)
+	}
+}
+
+func TestNotifierReloadsExitCodes(t *testing.T) {
+	codes := []int{0}
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllConfigInfo": func(args []interface{}) (interface{}, error) {
+			return []ProgramConfig{{Name: "web", Group: "web", ExitCodes: codes}}, nil
+		},
+		"supervisor.tailProcessStderrLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", 0, false}, nil
+		},
+	})
+	sink := &recordingSink{}
+	notifier := NewNotifier(ts.client(t), NotifierConfig{}, sink)
+	now := time.Unix(5000, 0)
+	notifier.now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+	running := ProcessInfo{Name: "web", Group: "web", State: ProcessRunning, StateName: "RUNNING", Pid: 10, Start: 1}
+	exited := ProcessInfo{Name: "web", Group: "web", State: ProcessExited, StateName: "EXITED", ExitStatus: 3, Start: 1}
+	ctx := context.Background()
+	notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited}})
+	// the group is re-added with exit code 3 expected
+	codes = []int{0, 3}
+	notifier.Handle(ctx, []Transition{
+		{Name: "web:web", From: exited, Removed: true},
+		{Name: "web:web", To: running, Added: true},
+	})
+	running.Start, exited.Start = 2, 2
+	notifier.Handle(ctx, []Transition{{Name: "web:web", From: running, To: exited}})
+	if len(sink.alerts) != 1 {
+		t.Fatalf("expected only the exit before the reload alerted but %+v", sink.alerts)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	sink := &WebhookSink{URL: server.URL}
+	if err := sink.Send(context.Background(), Alert{Name: "web:web", To: "FATAL"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "web:web" || got.To != "FATAL" {
+		t.Fatalf("unexpected webhook payload %+v", got)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "alert")
+	sink := &CommandSink{Path: "/bin/sh", Args: []string{"-c", `echo "$SUPERVISOR_ALERT_NAME" > ` + out}}
+	if err := sink.Send(context.Background(), Alert{Name: "web:web"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "web:web\n" {
+		t.Fatalf("unexpected command output %q", data)
+	}
+}
@@ -0,0 +1,282 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CycleError The dependency graph contains a cycle
+type CycleError struct {
+	Cycle []string // first and last element are the same program
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// Readiness How the orchestrator decides a RUNNING program is ready, zero values use the defaults
+type Readiness struct {
+	Probe      Probe          // e.g. a TCPProbe waiting for the port to open
+	LogPattern *regexp.Regexp // matched against stdout written after the start, skipped when already running
+	Timeout    time.Duration  // default 60 seconds
+}
+
+// Orchestrator Start programs in dependency order waiting until each one is
+// RUNNING and ready, and stop them in reverse order
+type Orchestrator struct {
+	client       Interface
+	deps         map[string][]string
+	readiness    map[string]Readiness
+	StartTimeout time.Duration // max wait for RUNNING, default 60 seconds
+	PollInterval time.Duration // default 500 milliseconds
+}
+
+// NewOrchestrator Create an orchestrator with an empty dependency graph
+func NewOrchestrator(client Interface) *Orchestrator {
+	return &Orchestrator{
+		client:       client,
+		deps:         make(map[string][]string),
+		readiness:    make(map[string]Readiness),
+		StartTimeout: time.Minute,
+		PollInterval: 500 * time.Millisecond,
+	}
+}
+
+// Add Add program name which must not start before dependsOn
+func (o *Orchestrator) Add(name string, dependsOn ...string) {
+	o.deps[name] = append(o.deps[name], dependsOn...)
+	for _, dep := range dependsOn {
+		if _, ok := o.deps[dep]; !ok {
+			o.deps[dep] = nil
+		}
+	}
+}
+
+// SetReadiness Set the readiness check of program name
+func (o *Orchestrator) SetReadiness(name string, readiness Readiness) {
+	if readiness.Timeout <= 0 {
+		readiness.Timeout = time.Minute
+	}
+	o.readiness[name] = readiness
+	if _, ok := o.deps[name]; !ok {
+		o.deps[name] = nil
+	}
+}
+
+// Order Return the programs in start order, dependencies first
+func (o *Orchestrator) Order() ([]string, error) {
+	if cycle := o.findCycle(); cycle != nil {
+		return nil, &CycleError{Cycle: cycle}
+	}
+	pending := make(map[string]int, len(o.deps))
+	dependents := make(map[string][]string)
+	for name, deps := range o.deps {
+		pending[name] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+	order := make([]string, 0, len(o.deps))
+	for len(pending) > 0 {
+		ready := make([]string, 0)
+		for name, n := range pending {
+			if n == 0 {
+				ready = append(ready, name)
+			}
+		}
+		sort.Strings(ready)
+		for _, name := range ready {
+			delete(pending, name)
+			for _, dependent := range dependents[name] {
+				pending[dependent]--
+			}
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+func (o *Orchestrator) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := make(map[string]int, len(o.deps))
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch marks[name] {
+		case visiting:
+			for i, p := range path {
+				if p == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		case done:
+			return nil
+		}
+		marks[name] = visiting
+		path = append(path, name)
+		deps := append([]string(nil), o.deps[name]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		marks[name] = done
+		return nil
+	}
+	names := make([]string, 0, len(o.deps))
+	for name := range o.deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Start Start every program in dependency order, each one must be RUNNING and
+// ready before its dependents are started
+func (o *Orchestrator) Start(ctx context.Context) error {
+	order, err := o.Order()
+	if err != nil {
+		return err
+	}
+	for _, name := range order {
+		if err := o.startOne(ctx, name); err != nil {
+			return fmt.Errorf("start %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Stop Stop every program in reverse dependency order
+func (o *Orchestrator) Stop(ctx context.Context) error {
+	order, err := o.Order()
+	if err != nil {
+		return err
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := order[i]
+		info, err := o.client.GetProcessInfo(name)
+		if err != nil {
+			return fmt.Errorf("stop %s: %w", name, err)
+		}
+		if info.State.IsRunning() {
+			if err := o.client.StopProcess(name, true); err != nil {
+				return fmt.Errorf("stop %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *Orchestrator) startOne(ctx context.Context, name string) error {
+	readiness, hasReadiness := o.readiness[name]
+	info, err := o.client.GetProcessInfo(name)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if info.State.IsRunning() {
+		// started before, the ready line is already somewhere in the log
+		readiness.LogPattern = nil
+	} else {
+		if hasReadiness && readiness.LogPattern != nil {
+			tail, err := o.client.TailProcessStdoutLog(name, 0, 0)
+			if err != nil {
+				return err
+			}
+			offset = int(tail.Offset)
+		}
+		if err := o.client.StartProcess(name, false); err != nil {
+			return err
+		}
+	}
+	if err := o.waitRunning(ctx, name); err != nil {
+		return err
+	}
+	if !hasReadiness {
+		return nil
+	}
+	return o.waitReady(ctx, name, readiness, offset)
+}
+
+func (o *Orchestrator) waitRunning(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.StartTimeout)
+	defer cancel()
+	for {
+		info, err := o.client.GetProcessInfo(name)
+		if err != nil {
+			return err
+		}
+		switch info.State {
+		case ProcessRunning:
+			return nil
+		case ProcessFatal:
+			return fmt.Errorf("process entered FATAL: %s", info.SpawnErr)
+		case ProcessStopped, ProcessExited:
+			return fmt.Errorf("process entered %s (exit status %d)", info.StateName, info.ExitStatus)
+		}
+		if err := sleepContext(ctx, o.PollInterval); err != nil {
+			return fmt.Errorf("waiting for RUNNING, last state %s: %w", info.StateName, err)
+		}
+	}
+}
+
+func (o *Orchestrator) waitReady(ctx context.Context, name string, readiness Readiness, offset int) error {
+	ctx, cancel := context.WithTimeout(ctx, readiness.Timeout)
+	defer cancel()
+	var output strings.Builder
+	for {
+		ready := true
+		if readiness.LogPattern != nil {
+			content, err := o.client.ReadProcessStdoutLog(name, offset+output.Len(), 0)
+			if err != nil {
+				return err
+			}
+			output.WriteString(content)
+			ready = readiness.LogPattern.MatchString(output.String())
+		}
+		var probeErr error
+		if ready && readiness.Probe != nil {
+			probeErr = readiness.Probe.Check(ctx)
+			ready = probeErr == nil
+		}
+		if ready {
+			return nil
+		}
+		if err := sleepContext(ctx, o.PollInterval); err != nil {
+			if probeErr != nil {
+				return fmt.Errorf("waiting for readiness: %v: %w", probeErr, err)
+			}
+			return fmt.Errorf("waiting for readiness: %w", err)
+		}
+	}
+}
+
+// sleepContext Sleep for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
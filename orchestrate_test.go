@@ -0,0 +1,109 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestOrchestratorOrder(t *testing.T) {
+	o := NewOrchestrator(nil)
+	o.Add("web", "api", "cache")
+	o.Add("api", "db")
+	o.Add("cache")
+	order, err := o.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"cache", "db", "api", "web"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected %v but %v", expected, order)
+	}
+
+	o.Add("db", "web")
+	_, err = o.Order()
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected cycle error but %v", err)
+	}
+	if expected := []string{"api", "db", "web", "api"}; !reflect.DeepEqual(cycle.Cycle, expected) {
+		t.Fatalf("expected cycle %v but %v", expected, cycle.Cycle)
+	}
+}
+
+func TestOrchestratorStartStop(t *testing.T) {
+	states := map[string]ProcessState{"db": ProcessStopped, "web": ProcessStopped}
+	var log []string
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			name := args[0].(string)
+			return ProcessInfo{Name: name, Group: name, State: states[name]}, nil
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			name := args[0].(string)
+			log = append(log, "start "+name)
+			states[name] = ProcessRunning
+			return true, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			name := args[0].(string)
+			log = append(log, "stop "+name)
+			states[name] = ProcessStopped
+			return true, nil
+		},
+		"supervisor.tailProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", 100, false}, nil
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			if args[1].(int64) != 100 {
+				return "", nil
+			}
+			log = append(log, "ready db")
+			return "db accepting connections\n", nil
+		},
+	})
+	o := NewOrchestrator(ts.client(t))
+	o.PollInterval = time.Millisecond
+	o.Add("web", "db")
+	o.SetReadiness("db", Readiness{LogPattern: regexp.MustCompile("accepting connections")})
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"start db", "ready db", "start web", "stop web", "stop db"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Fatalf("expected %v but %v", expected, log)
+	}
+}
+
+func TestOrchestratorStartAlreadyRunning(t *testing.T) {
+	states := map[string]ProcessState{"db": ProcessRunning, "web": ProcessBackoff}
+	polls := 0
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getProcessInfo": func(args []interface{}) (interface{}, error) {
+			name := args[0].(string)
+			if polls++; polls > 3 {
+				states["web"] = ProcessRunning
+			}
+			return ProcessInfo{Name: name, Group: name, State: states[name]}, nil
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			return nil, testFault{StatusAlreadyStarted, "ALREADY_STARTED"}
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return "", nil
+		},
+	})
+	o := NewOrchestrator(ts.client(t))
+	o.PollInterval = time.Millisecond
+	o.Add("web", "db")
+	o.SetReadiness("db", Readiness{LogPattern: regexp.MustCompile("accepting connections"), Timeout: time.Second})
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatalf("expected running and backing off programs left alone but %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/kolo/xmlrpc"
+)
+
+// Interaction A single recorded xml rpc call and the raw response of supervisord
+type Interaction struct {
+	Method     string        `json:"method"`
+	Args       []interface{} `json:"args"`
+	StatusCode int           `json:"status_code"`
+	Response   string        `json:"response"`
+}
+
+// Cassette The fixture file written by Recorder and read by Replayer
+type Cassette struct {
+	URL          string        `json:"url"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette Read a cassette from the fixture file at path
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := &Cassette{}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("decode cassette %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+// Save Write the cassette to the fixture file at path
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Recorder An http.RoundTripper which forwards requests to a live supervisord
+// and records every request/response pair, call Save to write the fixture file.
+// Basic auth credentials are never recorded.
+type Recorder struct {
+	path      string
+	transport http.RoundTripper
+	mu        sync.Mutex
+	cassette  Cassette
+}
+
+// NewRecorder Create a recorder writing to path, a nil transport means http.DefaultTransport
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{
+		path:      path,
+		transport: transport,
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	method, args, err := decodeMethodCall(body)
+	if err != nil {
+		return nil, err
+	}
+	forward := req.Clone(req.Context())
+	forward.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.transport.RoundTrip(forward)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(content))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cassette.URL == "" {
+		r.cassette.URL = redactURL(req.URL)
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:     method,
+		Args:       args,
+		StatusCode: resp.StatusCode,
+		Response:   string(content),
+	})
+	return resp, nil
+}
+
+// Interactions Return a copy of the interactions recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save Write the recorded interactions to the fixture file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Replayer An http.RoundTripper which answers requests from a cassette without
+// a live supervisord. A request matches an interaction with the same method name
+// and arguments, matching interactions are replayed in recorded order and each
+// one is used only once.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer Create a replayer from the fixture file at path
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCassetteReplayer(cassette), nil
+}
+
+// NewCassetteReplayer Create a replayer from an already loaded cassette
+func NewCassetteReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	method, args, err := decodeMethodCall(body)
+	if err != nil {
+		return nil, err
+	}
+	key, err := argsKey(args)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Method != method {
+			continue
+		}
+		recorded, err := argsKey(interaction.Args)
+		if err != nil {
+			return nil, err
+		}
+		if recorded != key {
+			continue
+		}
+		r.used[i] = true
+		return replayResponse(req, interaction), nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s(%s)", method, key)
+}
+
+// Unused Return the interactions which have not been replayed yet
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unused := make([]Interaction, 0)
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+func replayResponse(req *http.Request, interaction Interaction) *http.Response {
+	status := interaction.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/xml"}},
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response))),
+		ContentLength: int64(len(interaction.Response)),
+		Request:       req,
+	}
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// argsKey Normalize arguments so recorded and live values compare equal
+func argsKey(args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	return redacted.String()
+}
+
+type methodCall struct {
+	Name   string `xml:"methodName"`
+	Params []struct {
+		Value struct {
+			Inner string `xml:",innerxml"`
+		} `xml:"value"`
+	} `xml:"params>param"`
+}
+
+// decodeMethodCall Decode an xml rpc request body into method name and arguments
+func decodeMethodCall(body []byte) (string, []interface{}, error) {
+	var call methodCall
+	if err := xml.Unmarshal(body, &call); err != nil {
+		return "", nil, fmt.Errorf("decode method call: %w", err)
+	}
+	args := make([]interface{}, 0, len(call.Params))
+	for _, param := range call.Params {
+		var arg interface{}
+		value := xmlrpc.Response("<value>" + param.Value.Inner + "</value>")
+		if err := value.Unmarshal(&arg); err != nil {
+			return "", nil, fmt.Errorf("decode %s argument: %w", call.Name, err)
+		}
+		args = append(args, arg)
+	}
+	return call.Name, args, nil
+}
@@ -0,0 +1,451 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogRecord One line of process output
+type LogRecord struct {
+	Time    time.Time `json:"time"`    // when the line was shipped, supervisord does not timestamp output
+	Process string    `json:"process"` // group:name
+	Stream  LogStream `json:"stream"`
+	Offset  int64     `json:"offset"` // byte offset of the line in the log
+	Line    string    `json:"line"`
+}
+
+// LogSink Deliver shipped log records somewhere
+type LogSink interface {
+	Ship(records []LogRecord) error
+}
+
+// ShipperCheckpoint Offsets of the shipped data keyed by group:name/stream
+type ShipperCheckpoint struct {
+	Offsets map[string]int64 `json:"offsets"`
+	Updated time.Time        `json:"updated"`
+}
+
+// LoadShipperCheckpoint Read a checkpoint file, a missing file is an empty checkpoint
+func LoadShipperCheckpoint(path string) (*ShipperCheckpoint, error) {
+	checkpoint := &ShipperCheckpoint{Offsets: make(map[string]int64)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("decode checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Offsets == nil {
+		checkpoint.Offsets = make(map[string]int64)
+	}
+	return checkpoint, nil
+}
+
+// Save Write the checkpoint to path atomically
+func (c *ShipperCheckpoint) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ShipperConfig Tune log shipping, zero values use the defaults
+type ShipperConfig struct {
+	Checkpoint string      // checkpoint file, empty means offsets are kept in memory only
+	ChunkSize  int         // max bytes per read, default 64KB
+	Streams    []LogStream // default stdout and stderr
+	// ForgetAfter Polls a process must be missing from the listing before its
+	// offsets are dropped, default 10. Processes vanish briefly during a reload
+	// or update and would be shipped again from 0 otherwise.
+	ForgetAfter int
+}
+
+// Shipper Follow the stdout and stderr logs of every process and ship complete
+// lines to sinks. Offsets only advance after every sink accepted a chunk, so
+// delivery is at least once: a sink failing after another one succeeded, or a
+// crash before the checkpoint is saved, ships those lines again.
+type Shipper struct {
+	client     ReadOnly
+	config     ShipperConfig
+	sinks      []LogSink
+	checkpoint *ShipperCheckpoint
+	missing    map[string]int // polls in a row a checkpoint key was not listed
+	now        func() time.Time
+}
+
+// NewShipper Create a shipper resuming from config.Checkpoint
+func NewShipper(client ReadOnly, config ShipperConfig, sinks ...LogSink) (*Shipper, error) {
+	if config.ChunkSize < 2 {
+		config.ChunkSize = 64 * 1024
+	}
+	if len(config.Streams) == 0 {
+		config.Streams = []LogStream{StdoutStream, StderrStream}
+	}
+	if config.ForgetAfter <= 0 {
+		config.ForgetAfter = 10
+	}
+	checkpoint := &ShipperCheckpoint{Offsets: make(map[string]int64)}
+	if config.Checkpoint != "" {
+		var err error
+		if checkpoint, err = LoadShipperCheckpoint(config.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+	return &Shipper{client: client, config: config, sinks: sinks, checkpoint: checkpoint, missing: make(map[string]int), now: time.Now}, nil
+}
+
+// Offsets Return the shipped offsets keyed by group:name/stream
+func (s *Shipper) Offsets() map[string]int64 {
+	offsets := make(map[string]int64, len(s.checkpoint.Offsets))
+	for key, offset := range s.checkpoint.Offsets {
+		offsets[key] = offset
+	}
+	return offsets
+}
+
+// Poll Ship everything written since the previous poll. Processes are listed
+// again on every poll so added ones are picked up from offset 0 and ones missing
+// for ForgetAfter polls are forgotten. Every process is shipped and the first
+// error is returned. A trailing line without a newline waits for the next poll.
+func (s *Shipper) Poll() (err error) {
+	infos, err := s.client.GetAllProcessInfo()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if saveErr := s.save(); err == nil {
+			err = saveErr
+		}
+	}()
+	var firstErr error
+	seen := make(map[string]bool)
+	for _, info := range infos {
+		for _, stream := range s.config.Streams {
+			key := info.FullName() + "/" + string(stream)
+			seen[key] = true
+			if err := s.ship(info.FullName(), stream, key); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("ship %s: %w", key, err)
+			}
+		}
+	}
+	for key := range s.checkpoint.Offsets {
+		if seen[key] {
+			delete(s.missing, key)
+			continue
+		}
+		if s.missing[key]++; s.missing[key] >= s.config.ForgetAfter {
+			delete(s.checkpoint.Offsets, key)
+			delete(s.missing, key)
+		}
+	}
+	return firstErr
+}
+
+// Run Poll every interval until ctx is done, failed polls do not stop the loop
+func (s *Shipper) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Poll(); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ship Ship the complete lines of one stream, a log shorter than the offset was
+// rotated or cleared and is shipped again from 0
+func (s *Shipper) ship(name string, stream LogStream, key string) error {
+	size, err := processLogSize(s.client, name, stream)
+	if err != nil {
+		return err
+	}
+	offset := s.checkpoint.Offsets[key]
+	if size < offset {
+		offset = 0
+	}
+	s.checkpoint.Offsets[key] = offset
+	for offset < size {
+		length := int64(s.config.ChunkSize)
+		if size-offset < length {
+			length = size - offset
+		}
+		content, err := readProcessLog(s.client, name, stream, int(offset), int(length))
+		if err != nil {
+			return err
+		}
+		end := strings.LastIndexByte(content, '\n') + 1
+		if end == 0 && len(content) == s.config.ChunkSize {
+			// a line longer than a chunk is shipped in pieces, the last byte is
+			// kept back so a newline right after the piece cannot make an empty line
+			end = len(content) - 1
+		}
+		if end == 0 {
+			return nil
+		}
+		now := s.now()
+		records := make([]LogRecord, 0)
+		for _, line := range splitLogLines(content[:end], offset) {
+			records = append(records, LogRecord{Time: now, Process: name, Stream: stream, Offset: line.offset, Line: line.text})
+		}
+		for _, sink := range s.sinks {
+			if err := sink.Ship(records); err != nil {
+				return err
+			}
+		}
+		offset += int64(end)
+		s.checkpoint.Offsets[key] = offset
+	}
+	return nil
+}
+
+func (s *Shipper) save() error {
+	if s.config.Checkpoint == "" {
+		return nil
+	}
+	s.checkpoint.Updated = s.now()
+	return s.checkpoint.Save(s.config.Checkpoint)
+}
+
+// JSONLinesSink Write every record as one JSON object per line
+type JSONLinesSink struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+// Ship Encode the records to W
+func (j *JSONLinesSink) Ship(records []LogRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	encoder := json.NewEncoder(j.W)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RotatingFileSink Write the lines of every process stream to
+// Dir/group_name.stream.log, rotating to .1 ... .MaxFiles when MaxBytes is reached
+type RotatingFileSink struct {
+	Dir      string
+	MaxBytes int64 // default 10MB
+	MaxFiles int   // rotated files kept, default 5
+	mu       sync.Mutex
+	files    map[string]*os.File
+	sizes    map[string]int64
+}
+
+// Ship Append the lines to the files of their process stream
+func (r *RotatingFileSink) Ship(records []LogRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.files == nil {
+		r.files, r.sizes = make(map[string]*os.File), make(map[string]int64)
+	}
+	for _, record := range records {
+		path := filepath.Join(r.Dir, strings.Replace(record.Process, ":", "_", -1)+"."+string(record.Stream)+".log")
+		line := record.Line + "\n"
+		f, err := r.open(path, int64(len(line)))
+		if err != nil {
+			return err
+		}
+		n, err := f.WriteString(line)
+		r.sizes[path] += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close Close every open file
+func (r *RotatingFileSink) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var err error
+	for path, f := range r.files {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		delete(r.files, path)
+	}
+	return err
+}
+
+// open Return the file for path, rotating it first when n more bytes do not fit
+func (r *RotatingFileSink) open(path string, n int64) (*os.File, error) {
+	maxBytes, maxFiles := r.MaxBytes, r.MaxFiles
+	if maxBytes <= 0 {
+		maxBytes = 10 * 1024 * 1024
+	}
+	if maxFiles <= 0 {
+		maxFiles = 5
+	}
+	f := r.files[path]
+	if f != nil && r.sizes[path] > 0 && r.sizes[path]+n > maxBytes {
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		delete(r.files, path)
+		for i := maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		}
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, err
+		}
+		f = nil
+	}
+	if f == nil {
+		var err error
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r.files[path], r.sizes[path] = f, stat.Size()
+	}
+	return f, nil
+}
+
+// SyslogSink Send every line as an RFC 5424 message to a local syslog socket.
+// Stdout lines have severity info and stderr lines severity err, the process
+// name is the APP-NAME and the stream the MSGID.
+type SyslogSink struct {
+	Network  string // default unixgram
+	Addr     string // default /dev/log
+	Hostname string // default os.Hostname
+	Facility int    // default 1 (user)
+	mu       sync.Mutex
+	conn     net.Conn
+}
+
+// Ship Send the records, reconnecting once when the socket went away
+func (s *SyslogSink) Ship(records []LogRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Hostname == "" {
+		s.Hostname, _ = os.Hostname()
+	}
+	for _, record := range records {
+		msg := formatSyslog(record, s.Facility, s.Hostname)
+		if err := s.send(msg); err != nil {
+			if s.conn != nil {
+				s.conn.Close()
+				s.conn = nil
+			}
+			if err := s.send(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close Close the socket
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) send(msg string) error {
+	if s.conn == nil {
+		network, addr := s.Network, s.Addr
+		if network == "" {
+			network = "unixgram"
+		}
+		if addr == "" {
+			addr = "/dev/log"
+		}
+		conn, err := net.Dial(network, addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if s.Network == "unix" || s.Network == "tcp" {
+		msg += "\n"
+	}
+	_, err := io.WriteString(s.conn, msg)
+	return err
+}
+
+const (
+	syslogSeverityErr  = 3
+	syslogSeverityInfo = 6
+)
+
+// formatSyslog Format an RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func formatSyslog(record LogRecord, facility int, hostname string) string {
+	if facility <= 0 {
+		facility = 1
+	}
+	severity := syslogSeverityInfo
+	if record.Stream == StderrStream {
+		severity = syslogSeverityErr
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		facility*8+severity,
+		record.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(hostname, 255),
+		syslogField(record.Process, 48),
+		syslogField(string(record.Stream), 32),
+		record.Line)
+}
+
+// syslogField Return s as a header field of printable ASCII of at most n bytes
+func syslogField(s string, n int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+	if field == "" {
+		return "-"
+	}
+	if len(field) > n {
+		field = field[:n]
+	}
+	return field
+}
@@ -0,0 +1,176 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShipper(t *testing.T) {
+	logs := map[string]string{"web:web": "one\ntwo\nthr"}
+	log := func(args []interface{}) string {
+		return logs[args[0].(string)]
+	}
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			infos := make([]ProcessInfo, 0)
+			for name := range logs {
+				parts := strings.SplitN(name, ":", 2)
+				infos = append(infos, ProcessInfo{Group: parts[0], Name: parts[1]})
+			}
+			return infos, nil
+		},
+		"supervisor.tailProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return []interface{}{"", len(log(args)), false}, nil
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			content, offset, length := log(args), int(args[1].(int64)), int(args[2].(int64))
+			if offset >= len(content) {
+				return "", nil
+			}
+			if end := offset + length; end < len(content) {
+				return content[offset:end], nil
+			}
+			return content[offset:], nil
+		},
+	})
+	cli := ts.client(t)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	var buf bytes.Buffer
+	config := ShipperConfig{Checkpoint: checkpoint, ChunkSize: 8, Streams: []LogStream{StdoutStream}}
+	shipper, err := NewShipper(cli, config, &JSONLinesSink{W: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shipper.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := shippedLines(t, &buf); got != "web:web one 0,web:web two 4" {
+		t.Fatalf("unexpected records %s", got)
+	}
+
+	// a new shipper resumes from the checkpoint and picks up new processes
+	logs["web:web"] += "ee\n"
+	logs["jobs:cron"] = "tick\n"
+	shipper, err = NewShipper(cli, config, &JSONLinesSink{W: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shipper.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	got := shippedLines(t, &buf)
+	if !strings.Contains(got, "web:web three 8") || !strings.Contains(got, "jobs:cron tick 0") || strings.Contains(got, "one") {
+		t.Fatalf("unexpected records after resume %s", got)
+	}
+
+	// a cleared log is shipped again from the start
+	logs["web:web"] = "new\n"
+	if err := shipper.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := shippedLines(t, &buf); got != "web:web new 0" {
+		t.Fatalf("unexpected records after clear %s", got)
+	}
+	saved, err := LoadShipperCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Offsets["web:web/stdout"] != 4 || saved.Offsets["jobs:cron/stdout"] != 5 {
+		t.Fatalf("unexpected checkpoint %+v", saved.Offsets)
+	}
+
+	// a process missing for a poll, e.g. during an update, is not shipped again
+	cron := logs["jobs:cron"]
+	delete(logs, "jobs:cron")
+	if err := shipper.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	logs["jobs:cron"] = cron
+	if err := shipper.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := shippedLines(t, &buf); got != "" {
+		t.Fatalf("unexpected records after a missing poll %s", got)
+	}
+}
+
+func TestShipperKeepsGoing(t *testing.T) {
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			return []ProcessInfo{{Group: "a", Name: "a"}, {Group: "b", Name: "b"}}, nil
+		},
+		"supervisor.tailProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			if args[0] == "a:a" {
+				return nil, testFault{StatusFailed, "FAILED"}
+			}
+			return []interface{}{"", 3, false}, nil
+		},
+		"supervisor.readProcessStdoutLog": func(args []interface{}) (interface{}, error) {
+			return "ok\n", nil
+		},
+	})
+	var buf bytes.Buffer
+	shipper, err := NewShipper(ts.client(t), ShipperConfig{Streams: []LogStream{StdoutStream}}, &JSONLinesSink{W: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shipper.Poll(); err == nil || !strings.Contains(err.Error(), "a:a") {
+		t.Fatalf("expected the error of a:a but %v", err)
+	}
+	if got := shippedLines(t, &buf); got != "b:b ok 0" {
+		t.Fatalf("expected b:b shipped despite a:a but %s", got)
+	}
+}
+
+func shippedLines(t *testing.T, buf *bytes.Buffer) string {
+	lines := make([]string, 0)
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record LogRecord
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, record.Process+" "+record.Line+" "+strconv.FormatInt(record.Offset, 10))
+	}
+	buf.Reset()
+	return strings.Join(lines, ",")
+}
+
+func TestRotatingFileSink(t *testing.T) {
+	dir := t.TempDir()
+	sink := &RotatingFileSink{Dir: dir, MaxBytes: 8, MaxFiles: 2}
+	defer sink.Close()
+	for _, line := range []string{"aaa", "bbb", "ccc", "ddd", "eee"} {
+		if err := sink.Ship([]LogRecord{{Process: "web:web", Stream: StdoutStream, Line: line}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]string{"web_web.stdout.log": "eee\n", "web_web.stdout.log.1": "ccc\nddd\n", "web_web.stdout.log.2": "aaa\nbbb\n"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Fatalf("%s: expected %q but %q", name, want, data)
+		}
+	}
+}
+
+func TestFormatSyslog(t *testing.T) {
+	record := LogRecord{
+		Time:    time.Date(2021, 1, 20, 10, 11, 12, 345000000, time.UTC),
+		Process: "web:web",
+		Stream:  StderrStream,
+		Line:    "boom",
+	}
+	want := "<11>1 2021-01-20T10:11:12.345000Z host web:web - stderr - boom"
+	if got := formatSyslog(record, 1, "host"); got != want {
+		t.Fatalf("expected %q but %q", want, got)
+	}
+}
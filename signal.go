@@ -0,0 +1,155 @@
+package supervisor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Signal A UNIX signal numbered like Linux. It is sent to supervisord by name,
+// so the numbering of the server platform does not matter.
+type Signal int
+
+const (
+	SignalHUP    Signal = 1
+	SignalINT    Signal = 2
+	SignalQUIT   Signal = 3
+	SignalILL    Signal = 4
+	SignalTRAP   Signal = 5
+	SignalABRT   Signal = 6
+	SignalBUS    Signal = 7
+	SignalFPE    Signal = 8
+	SignalKILL   Signal = 9
+	SignalUSR1   Signal = 10
+	SignalSEGV   Signal = 11
+	SignalUSR2   Signal = 12
+	SignalPIPE   Signal = 13
+	SignalALRM   Signal = 14
+	SignalTERM   Signal = 15
+	SignalSTKFLT Signal = 16
+	SignalCHLD   Signal = 17
+	SignalCONT   Signal = 18
+	SignalSTOP   Signal = 19
+	SignalTSTP   Signal = 20
+	SignalTTIN   Signal = 21
+	SignalTTOU   Signal = 22
+	SignalURG    Signal = 23
+	SignalXCPU   Signal = 24
+	SignalXFSZ   Signal = 25
+	SignalVTALRM Signal = 26
+	SignalPROF   Signal = 27
+	SignalWINCH  Signal = 28
+	SignalIO     Signal = 29
+	SignalPWR    Signal = 30
+	SignalSYS    Signal = 31
+)
+
+// signalNames The signals supervisord accepts by name, see supervisor/datatypes.py signal_number
+var signalNames = map[Signal]string{
+	SignalHUP:    "HUP",
+	SignalINT:    "INT",
+	SignalQUIT:   "QUIT",
+	SignalILL:    "ILL",
+	SignalTRAP:   "TRAP",
+	SignalABRT:   "ABRT",
+	SignalBUS:    "BUS",
+	SignalFPE:    "FPE",
+	SignalKILL:   "KILL",
+	SignalUSR1:   "USR1",
+	SignalSEGV:   "SEGV",
+	SignalUSR2:   "USR2",
+	SignalPIPE:   "PIPE",
+	SignalALRM:   "ALRM",
+	SignalTERM:   "TERM",
+	SignalSTKFLT: "STKFLT",
+	SignalCHLD:   "CHLD",
+	SignalCONT:   "CONT",
+	SignalSTOP:   "STOP",
+	SignalTSTP:   "TSTP",
+	SignalTTIN:   "TTIN",
+	SignalTTOU:   "TTOU",
+	SignalURG:    "URG",
+	SignalXCPU:   "XCPU",
+	SignalXFSZ:   "XFSZ",
+	SignalVTALRM: "VTALRM",
+	SignalPROF:   "PROF",
+	SignalWINCH:  "WINCH",
+	SignalIO:     "IO",
+	SignalPWR:    "PWR",
+	SignalSYS:    "SYS",
+}
+
+var signalAliases = map[string]Signal{
+	"IOT":  SignalABRT,
+	"CLD":  SignalCHLD,
+	"POLL": SignalIO,
+}
+
+// String Return the canonical name without the SIG prefix, e.g. HUP
+func (s Signal) String() string {
+	if name, ok := signalNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// Valid Report whether supervisord accepts the signal
+func (s Signal) Valid() bool {
+	_, ok := signalNames[s]
+	return ok
+}
+
+// ParseSignal Parse a signal name like HUP or SIGHUP in any case, or its number
+func ParseSignal(s string) (Signal, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	name = strings.TrimPrefix(name, "SIG")
+	for signal, signalName := range signalNames {
+		if signalName == name {
+			return signal, nil
+		}
+	}
+	if signal, ok := signalAliases[name]; ok {
+		return signal, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && Signal(n).Valid() {
+		return Signal(n), nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrBadSignal, s)
+}
+
+func (s Signal) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Signal) UnmarshalText(text []byte) error {
+	signal, err := ParseSignal(string(text))
+	if err != nil {
+		return err
+	}
+	*s = signal
+	return nil
+}
+
+// UnmarshalJSON Accept the name as well as the number
+func (s *Signal) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, s.UnmarshalText)
+}
+
+// wireSignal Return the name sent to supervisord, ErrBadSignal for signals it rejects
+func wireSignal(s Signal) (string, error) {
+	if !s.Valid() {
+		return "", fmt.Errorf("%w: %d", ErrBadSignal, int(s))
+	}
+	return s.String(), nil
+}
+
+// decodeSignal Decode a signal sent as a number or a name
+func decodeSignal(value interface{}) (Signal, error) {
+	switch v := value.(type) {
+	case int64:
+		return Signal(v), nil
+	case string:
+		return ParseSignal(v)
+	}
+	return 0, fmt.Errorf("%w: unexpected %T", ErrBadSignal, value)
+}
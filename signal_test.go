@@ -0,0 +1,78 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	for input, want := range map[string]Signal{"HUP": SignalHUP, "sigusr2": SignalUSR2, " SIGTERM": SignalTERM, "9": SignalKILL, "IOT": SignalABRT} {
+		signal, err := ParseSignal(input)
+		if err != nil || signal != want {
+			t.Fatalf("%q: expected %v but %v %v", input, want, signal, err)
+		}
+		if again, err := ParseSignal(signal.String()); err != nil || again != signal {
+			t.Fatalf("%v does not round-trip", signal)
+		}
+	}
+	for _, input := range []string{"SIGFOO", "0", "99", ""} {
+		if _, err := ParseSignal(input); !errors.Is(err, ErrBadSignal) {
+			t.Fatalf("%q: expected ErrBadSignal but %v", input, err)
+		}
+	}
+	var config ProgramConfig
+	if err := json.Unmarshal([]byte(`{"stopsignal": 15}`), &config); err != nil || config.StopSignal != SignalTERM {
+		t.Fatalf("unexpected stopsignal %v %v", config.StopSignal, err)
+	}
+	data, _ := json.Marshal(config)
+	if err := json.Unmarshal(data, &config); err != nil || config.StopSignal != SignalTERM {
+		t.Fatalf("stopsignal does not round-trip through %s", data)
+	}
+}
+
+func TestSignalOnTheWire(t *testing.T) {
+	var sent []interface{}
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.signalProcess": func(args []interface{}) (interface{}, error) {
+			sent = args
+			return true, nil
+		},
+		"supervisor.getAllConfigInfo": func(args []interface{}) (interface{}, error) {
+			return []map[string]interface{}{
+				{"name": "web", "group": "web", "stopsignal": 15, "exitcodes": []int{0, 2}, "autostart": true},
+				{"name": "worker", "group": "jobs", "stopsignal": "QUIT"},
+			}, nil
+		},
+	})
+	cli := ts.client(t)
+	if err := cli.SignalProcessNamed("web", SignalUSR1); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[1] != "USR1" {
+		t.Fatalf("expected the signal name on the wire but %v", sent)
+	}
+	if err := cli.SignalProcess("web", syscall.SIGTERM); err != nil || sent[1] != "TERM" {
+		t.Fatalf("expected syscall signals sent by name but %v %v", sent, err)
+	}
+	if err := cli.SignalProcessNamed("web", Signal(99)); !errors.Is(err, ErrBadSignal) {
+		t.Fatalf("expected a local ErrBadSignal but %v", err)
+	}
+	signals := 0
+	for _, call := range ts.Calls() {
+		if call == "supervisor.signalProcess" {
+			signals++
+		}
+	}
+	if signals != 2 {
+		t.Fatalf("a bad signal must not reach the server: %v", ts.Calls())
+	}
+	configs, err := cli.GetAllConfigInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs[0].StopSignal != SignalTERM || configs[1].StopSignal != SignalQUIT || !configs[0].Autostart || len(configs[0].ExitCodes) != 2 {
+		t.Fatalf("unexpected configs %+v", configs)
+	}
+}
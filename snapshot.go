@@ -0,0 +1,138 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// SnapshotProcess The recorded state of one process
+type SnapshotProcess struct {
+	Name      string       `json:"name"` // group:name
+	State     ProcessState `json:"state"`
+	StateName string       `json:"statename"`
+	Running   bool         `json:"running"`
+}
+
+// Snapshot The state of every process of a supervisord at one point in time
+type Snapshot struct {
+	Taken     time.Time         `json:"taken"`
+	Processes []SnapshotProcess `json:"processes"`
+}
+
+// ReadSnapshot Decode a snapshot written by Snapshot.WriteTo
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// WriteTo Encode the snapshot as indented JSON
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// RestoreAction What RestoreSnapshot does with one process
+type RestoreAction string
+
+const (
+	RestoreNone    RestoreAction = "none"    // already in the recorded state
+	RestoreStart   RestoreAction = "start"   // was running, is not
+	RestoreStop    RestoreAction = "stop"    // was stopped, is running
+	RestoreMissing RestoreAction = "missing" // no longer known to supervisord
+)
+
+// RestoreResult The planned or applied action for one process
+type RestoreResult struct {
+	Name    string
+	Action  RestoreAction
+	Current *ProcessState // nil when the process is missing
+	Desired ProcessState
+	Err     error
+}
+
+// TakeSnapshot Capture the state of every process
+func TakeSnapshot(client ReadOnly) (*Snapshot, error) {
+	infos, err := client.GetAllProcessInfo()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		Taken:     time.Now(),
+		Processes: make([]SnapshotProcess, 0, len(infos)),
+	}
+	for _, info := range infos {
+		snapshot.Processes = append(snapshot.Processes, SnapshotProcess{
+			Name:      info.FullName(),
+			State:     info.State,
+			StateName: info.StateName,
+			Running:   info.State.IsRunning(),
+		})
+	}
+	sort.Slice(snapshot.Processes, func(i, j int) bool {
+		return snapshot.Processes[i].Name < snapshot.Processes[j].Name
+	})
+	return snapshot, nil
+}
+
+// DiffSnapshot Preview what RestoreSnapshot would do, nothing is changed on the server
+func DiffSnapshot(client ReadOnly, snapshot *Snapshot) ([]RestoreResult, error) {
+	infos, err := client.GetAllProcessInfo()
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]ProcessInfo, len(infos))
+	for _, info := range infos {
+		current[info.FullName()] = info
+	}
+	results := make([]RestoreResult, 0, len(snapshot.Processes))
+	for _, p := range snapshot.Processes {
+		result := RestoreResult{Name: p.Name, Action: RestoreNone, Desired: p.State}
+		info, ok := current[p.Name]
+		if ok {
+			state := info.State
+			result.Current = &state
+		}
+		switch {
+		case !ok:
+			result.Action = RestoreMissing
+		case p.Running && !info.State.IsRunning():
+			result.Action = RestoreStart
+		case !p.Running && info.State.IsRunning():
+			result.Action = RestoreStop
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// RestoreSnapshot Bring the server back to the snapshot, starting what was
+// running and stopping what was stopped. Processes added after the snapshot are
+// left alone, every process is attempted and its error recorded in the result.
+func RestoreSnapshot(client Interface, snapshot *Snapshot, wait bool) ([]RestoreResult, error) {
+	results, err := DiffSnapshot(client, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		result := &results[i]
+		switch result.Action {
+		case RestoreStart:
+			result.Err = client.StartProcess(result.Name, wait)
+		case RestoreStop:
+			result.Err = client.StopProcess(result.Name, wait)
+		case RestoreMissing:
+			result.Err = fmt.Errorf("process %s no longer exists", result.Name)
+		}
+	}
+	return results, nil
+}
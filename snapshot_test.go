@@ -0,0 +1,72 @@
+package supervisor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	states := map[string]ProcessState{"web": ProcessRunning, "worker": ProcessRunning, "cron": ProcessStopped}
+	ts := newTestServer(t, map[string]testHandler{
+		"supervisor.getAllProcessInfo": func(args []interface{}) (interface{}, error) {
+			infos := make([]ProcessInfo, 0)
+			for name, state := range states {
+				infos = append(infos, ProcessInfo{Name: name, Group: name, State: state})
+			}
+			return infos, nil
+		},
+		"supervisor.startProcess": func(args []interface{}) (interface{}, error) {
+			states[strings.SplitN(args[0].(string), ":", 2)[1]] = ProcessRunning
+			return true, nil
+		},
+		"supervisor.stopProcess": func(args []interface{}) (interface{}, error) {
+			states[strings.SplitN(args[0].(string), ":", 2)[1]] = ProcessStopped
+			return true, nil
+		},
+	})
+	cli := ts.client(t)
+	snapshot, err := TakeSnapshot(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := snapshot.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err = ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Processes) != 3 || snapshot.Processes[0].Name != "cron:cron" {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+
+	// after a restart web is gone from the running set and cron was started
+	states["web"] = ProcessStopped
+	states["cron"] = ProcessRunning
+	delete(states, "worker")
+	results, err := RestoreSnapshot(cli, snapshot, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[string]RestoreAction)
+	for _, result := range results {
+		actions[result.Name] = result.Action
+		if result.Action != RestoreMissing && result.Err != nil {
+			t.Fatalf("%s: %v", result.Name, result.Err)
+		}
+		if (result.Action == RestoreMissing) != (result.Current == nil) {
+			t.Fatalf("%s: unexpected current state for action %s", result.Name, result.Action)
+		}
+	}
+	if *results[0].Current != ProcessRunning {
+		t.Fatalf("expected cron:cron currently running but %v", *results[0].Current)
+	}
+	if actions["web:web"] != RestoreStart || actions["cron:cron"] != RestoreStop || actions["worker:worker"] != RestoreMissing {
+		t.Fatalf("unexpected restore actions %v", actions)
+	}
+	if states["web"] != ProcessRunning || states["cron"] != ProcessStopped {
+		t.Fatalf("server not restored: %v", states)
+	}
+}
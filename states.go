@@ -0,0 +1,221 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var statusNames = map[Status]string{
+	StatusUnknownMethod:        "UNKNOWN_METHOD",
+	StatusIncorrectParameters:  "INCORRECT_PARAMETERS",
+	StatusBadArguments:         "BAD_ARGUMENTS",
+	StatusSignatureUnsupported: "SIGNATURE_UNSUPPORTED",
+	StatusShutdownState:        "SHUTDOWN_STATE",
+	StatusBadName:              "BAD_NAME",
+	StatusBadSignal:            "BAD_SIGNAL",
+	StatusNoFile:               "NO_FILE",
+	StatusNotExecutable:        "NOT_EXECUTABLE",
+	StatusFailed:               "FAILED",
+	StatusAbnormalTermination:  "ABNORMAL_TERMINATION",
+	StatusSpawnError:           "SPAWN_ERROR",
+	StatusAlreadyStarted:       "ALREADY_STARTED",
+	StatusNotRunning:           "NOT_RUNNING",
+	StatusSuccess:              "SUCCESS",
+	StatusAlreadyAdded:         "ALREADY_ADDED",
+	StatusStillRunning:         "STILL_RUNNING",
+	StatusCantReread:           "CANT_REREAD",
+}
+
+var processStateNames = map[ProcessState]string{
+	ProcessStopped:  "STOPPED",
+	ProcessStarting: "STARTING",
+	ProcessRunning:  "RUNNING",
+	ProcessBackoff:  "BACKOFF",
+	ProcessStopping: "STOPPING",
+	ProcessExited:   "EXITED",
+	ProcessFatal:    "FATAL",
+	ProcessUnknown:  "UNKNOWN",
+}
+
+var stateNames = map[State]string{
+	ServerFatal:      "FATAL",
+	ServerRunning:    "RUNNING",
+	ServerRestarting: "RESTARTING",
+	ServerShutdown:   "SHUTDOWN",
+}
+
+// processTransitions The state changes supervisord's process state machine
+// performs, see supervisor/process.py
+var processTransitions = map[ProcessState][]ProcessState{
+	ProcessStopped:  {ProcessStarting},
+	ProcessStarting: {ProcessRunning, ProcessBackoff, ProcessStopping},
+	ProcessRunning:  {ProcessStopping, ProcessExited},
+	ProcessBackoff:  {ProcessStarting, ProcessStopped, ProcessFatal},
+	ProcessStopping: {ProcessStopped},
+	ProcessExited:   {ProcessStarting},
+	ProcessFatal:    {ProcessStarting},
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseStatus Parse a fault or status name like NOT_RUNNING, or its number
+func ParseStatus(s string) (Status, error) {
+	for status, name := range statusNames {
+		if strings.EqualFold(name, s) {
+			return status, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if _, ok := statusNames[Status(n)]; ok {
+			return Status(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status %q", s)
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Status) UnmarshalText(text []byte) error {
+	status, err := ParseStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// UnmarshalJSON Accept the name as well as the number
+func (s *Status) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, s.UnmarshalText)
+}
+
+func (s ProcessState) String() string {
+	if name, ok := processStateNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseProcessState Parse a process state name like RUNNING, or its number
+func ParseProcessState(s string) (ProcessState, error) {
+	for state, name := range processStateNames {
+		if strings.EqualFold(name, s) {
+			return state, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if _, ok := processStateNames[ProcessState(n)]; ok {
+			return ProcessState(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown process state %q", s)
+}
+
+func (s ProcessState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ProcessState) UnmarshalText(text []byte) error {
+	state, err := ParseProcessState(string(text))
+	if err != nil {
+		return err
+	}
+	*s = state
+	return nil
+}
+
+// UnmarshalJSON Accept the name as well as the number
+func (s *ProcessState) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, s.UnmarshalText)
+}
+
+// IsRunning Report whether the state is one of supervisord's RUNNING_STATES: RUNNING, BACKOFF, STARTING
+func (s ProcessState) IsRunning() bool {
+	return s == ProcessRunning || s == ProcessBackoff || s == ProcessStarting
+}
+
+// IsStopped Report whether the state is one of supervisord's STOPPED_STATES: STOPPED, EXITED, FATAL, UNKNOWN
+func (s ProcessState) IsStopped() bool {
+	return s == ProcessStopped || s == ProcessExited || s == ProcessFatal || s == ProcessUnknown
+}
+
+// IsSignallable Report whether the state is one of supervisord's SIGNALLABLE_STATES: RUNNING, STARTING, STOPPING
+func (s ProcessState) IsSignallable() bool {
+	return s == ProcessRunning || s == ProcessStarting || s == ProcessStopping
+}
+
+// CanTransitionTo Report whether supervisord moves a process from s to next in
+// one step. Staying in the same state and anything involving UNKNOWN is allowed.
+func (s ProcessState) CanTransitionTo(next ProcessState) bool {
+	if s == next || s == ProcessUnknown || next == ProcessUnknown {
+		return true
+	}
+	for _, to := range processTransitions[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseState Parse a supervisord state name like RUNNING, or its number
+func ParseState(s string) (State, error) {
+	for state, name := range stateNames {
+		if strings.EqualFold(name, s) {
+			return state, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if _, ok := stateNames[State(n)]; ok {
+			return State(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown state %q", s)
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *State) UnmarshalText(text []byte) error {
+	state, err := ParseState(string(text))
+	if err != nil {
+		return err
+	}
+	*s = state
+	return nil
+}
+
+// UnmarshalJSON Accept the name as well as the number
+func (s *State) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, s.UnmarshalText)
+}
+
+// unmarshalEnumJSON Decode a JSON string or number with unmarshalText
+func unmarshalEnumJSON(data []byte, unmarshalText func([]byte) error) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		return unmarshalText([]byte(name))
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	return unmarshalText([]byte(n.String()))
+}
@@ -0,0 +1,81 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProcessStateNames(t *testing.T) {
+	for state, name := range processStateNames {
+		if state.String() != name {
+			t.Errorf("%d.String() = %s, want %s", state, state.String(), name)
+		}
+		parsed, err := ParseProcessState(name)
+		if err != nil || parsed != state {
+			t.Errorf("ParseProcessState(%s) = %d, %v", name, parsed, err)
+		}
+	}
+	if _, err := ParseProcessState("SLEEPING"); err == nil {
+		t.Error("expected error for unknown state")
+	}
+	if s, err := ParseStatus("not_running"); err != nil || s != StatusNotRunning {
+		t.Errorf("ParseStatus(not_running) = %d, %v", s, err)
+	}
+	if s, err := ParseStatus("70"); err != nil || s != StatusNotRunning {
+		t.Errorf("ParseStatus(70) = %d, %v", s, err)
+	}
+	if _, err := ParseStatus("12345"); err == nil {
+		t.Error("expected error for unknown status")
+	}
+	if s, err := ParseState("-1"); err != nil || s != ServerShutdown {
+		t.Errorf("ParseState(-1) = %d, %v", s, err)
+	}
+}
+
+func TestProcessStateJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ State ProcessState }{ProcessBackoff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"State":"BACKOFF"}` {
+		t.Fatalf("unexpected encoding %s", data)
+	}
+	var decoded struct{ State ProcessState }
+	for _, input := range []string{`{"State":"BACKOFF"}`, `{"State":30}`} {
+		if err := json.Unmarshal([]byte(input), &decoded); err != nil || decoded.State != ProcessBackoff {
+			t.Fatalf("decode %s: %d %v", input, decoded.State, err)
+		}
+	}
+}
+
+func TestProcessStateClasses(t *testing.T) {
+	if !ProcessBackoff.IsRunning() || ProcessStopping.IsRunning() {
+		t.Error("unexpected RUNNING_STATES")
+	}
+	if !ProcessFatal.IsStopped() || ProcessStopping.IsStopped() {
+		t.Error("unexpected STOPPED_STATES")
+	}
+	if !ProcessStopping.IsSignallable() || ProcessBackoff.IsSignallable() {
+		t.Error("unexpected SIGNALLABLE_STATES")
+	}
+}
+
+func TestProcessStateTransitions(t *testing.T) {
+	cases := []struct {
+		from, to ProcessState
+		valid    bool
+	}{
+		{ProcessStopped, ProcessStarting, true},
+		{ProcessStarting, ProcessRunning, true},
+		{ProcessRunning, ProcessExited, true},
+		{ProcessBackoff, ProcessFatal, true},
+		{ProcessStopped, ProcessRunning, false},
+		{ProcessFatal, ProcessRunning, false},
+		{ProcessStarting, ProcessExited, false},
+	}
+	for _, c := range cases {
+		if got := c.from.CanTransitionTo(c.to); got != c.valid {
+			t.Errorf("%s -> %s valid = %t, want %t", c.from, c.to, got, c.valid)
+		}
+	}
+}
@@ -0,0 +1,832 @@
+// Package supervisortest provides an in-memory supervisor.Interface for unit
+// tests of code that talks to supervisord.
+package supervisortest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	sc "github.com/lixianyang/supervisor-client"
+)
+
+// Call One recorded method call
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Fault Return the fault supervisord answers with for code
+func Fault(code sc.Status) *sc.Fault {
+	return &sc.Fault{Code: code, String: code.String()}
+}
+
+// Fake An in-memory supervisord. Processes behave like real ones: starting one
+// makes it RUNNING and stopping it STOPPED, with the faults supervisord returns
+// for unknown names and processes in the wrong state. Results and faults can be
+// programmed per method, and every call is recorded. Safe for concurrent use.
+type Fake struct {
+	mu        sync.Mutex
+	calls     []Call
+	processes map[string]*sc.ProcessInfo // by group:name
+	configs   []sc.ProgramConfig
+	stdout    map[string]string
+	stderr    map[string]string
+	stdin     map[string]string
+	mainLog   string
+	statuses  map[string][]sc.ActionStatus
+	faults    map[string]error // by method, or method and process name
+	reload    [3][]string
+	state     sc.ServerState
+	methods   []string
+	results   map[string]interface{} // Call and Multicall results by full method name
+	nextPid   int
+}
+
+var _ sc.Interface = (*Fake)(nil)
+
+// NewFake Create a fake with processes, a zero State means STOPPED
+func NewFake(processes ...sc.ProcessInfo) *Fake {
+	f := &Fake{
+		processes: make(map[string]*sc.ProcessInfo),
+		stdout:    make(map[string]string),
+		stderr:    make(map[string]string),
+		stdin:     make(map[string]string),
+		statuses:  make(map[string][]sc.ActionStatus),
+		faults:    make(map[string]error),
+		results:   make(map[string]interface{}),
+		state:     sc.ServerState{Code: sc.ServerRunning, Name: "RUNNING"},
+		nextPid:   1000,
+	}
+	for _, info := range processes {
+		f.SetProcess(info)
+	}
+	return f
+}
+
+// SetProcess Add or replace a process
+func (f *Fake) SetProcess(info sc.ProcessInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if info.Group == "" {
+		info.Group = info.Name
+	}
+	info.StateName = info.State.String()
+	f.processes[info.FullName()] = &info
+}
+
+// RemoveProcess Forget the process group:name
+func (f *Fake) RemoveProcess(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.processes, name)
+}
+
+// SetConfigs Set the result of GetAllConfigInfo, AddProcessGroup creates the
+// processes of a group from them
+func (f *Fake) SetConfigs(configs ...sc.ProgramConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configs = append([]sc.ProgramConfig(nil), configs...)
+}
+
+// SetStatuses Return statuses from method, e.g. StopAllProcesses, instead of
+// simulating it
+func (f *Fake) SetStatuses(method string, statuses []sc.ActionStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[method] = statuses
+}
+
+// SetReload Set the result of ReloadConfig
+func (f *Fake) SetReload(added, changed, removed []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reload = [3][]string{added, changed, removed}
+}
+
+// SetMethods Set the result of ListMethods, default every method of Interface
+func (f *Fake) SetMethods(methods ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.methods = methods
+}
+
+// SetCallResult Set the result of the full method name, e.g.
+// "twiddler.getGroupNames", for Call, CallMethod and Multicall. Other methods
+// answer those with UNKNOWN_METHOD.
+func (f *Fake) SetCallResult(name string, result interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[name] = result
+}
+
+// Fail Make every call of method return err, nil removes the fault
+func (f *Fake) Fail(method string, err error) {
+	f.FailProcess(method, "", err)
+}
+
+// FailProcess Make calls of method for process name return err, nil removes the fault
+func (f *Fake) FailProcess(method, name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimSpace(method + " " + name)
+	if err == nil {
+		delete(f.faults, key)
+		return
+	}
+	f.faults[key] = err
+}
+
+// AppendStdout Append output to the stdout log of group:name
+func (f *Fake) AppendStdout(name, output string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stdout[name] += output
+}
+
+// AppendStderr Append output to the stderr log of group:name
+func (f *Fake) AppendStderr(name, output string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stderr[name] += output
+}
+
+// AppendMainLog Append lines to the supervisord main log
+func (f *Fake) AppendMainLog(output string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mainLog += output
+}
+
+// Stdin Return everything sent to the stdin of group:name
+func (f *Fake) Stdin(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stdin[name]
+}
+
+// Calls Return the recorded calls in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo Return the recorded calls of method
+func (f *Fake) CallsTo(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := make([]Call, 0)
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// begin Record a call and return its programmed fault, the caller holds f.mu
+func (f *Fake) begin(method string, args ...interface{}) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	if len(args) > 0 {
+		if name, ok := args[0].(string); ok {
+			if err := f.faults[method+" "+name]; err != nil {
+				return err
+			}
+		}
+	}
+	return f.faults[method]
+}
+
+// lookup Find a process by group:name, or by name when group and name are equal
+func (f *Fake) lookup(name string) (*sc.ProcessInfo, error) {
+	if info, ok := f.processes[name]; ok {
+		return info, nil
+	}
+	if info, ok := f.processes[name+":"+name]; ok && !strings.Contains(name, ":") {
+		return info, nil
+	}
+	return nil, Fault(sc.StatusBadName)
+}
+
+// sorted Return the processes matching keep ordered by group:name
+func (f *Fake) sorted(keep func(*sc.ProcessInfo) bool) []*sc.ProcessInfo {
+	names := make([]string, 0, len(f.processes))
+	for name, info := range f.processes {
+		if keep(info) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	infos := make([]*sc.ProcessInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, f.processes[name])
+	}
+	return infos
+}
+
+func (f *Fake) group(name string) ([]*sc.ProcessInfo, error) {
+	infos := f.sorted(func(info *sc.ProcessInfo) bool { return info.Group == name })
+	if len(infos) == 0 {
+		return nil, Fault(sc.StatusBadName)
+	}
+	return infos, nil
+}
+
+func (f *Fake) all() []*sc.ProcessInfo {
+	return f.sorted(func(*sc.ProcessInfo) bool { return true })
+}
+
+// each Apply action to infos and return a status per process, or the
+// programmed statuses of method
+func (f *Fake) each(method string, infos []*sc.ProcessInfo, action func(*sc.ProcessInfo) error) []sc.ActionStatus {
+	if statuses, ok := f.statuses[method]; ok {
+		return append([]sc.ActionStatus(nil), statuses...)
+	}
+	statuses := make([]sc.ActionStatus, 0, len(infos))
+	for _, info := range infos {
+		status := sc.ActionStatus{Name: info.Name, Group: info.Group, Status: sc.StatusSuccess, Description: "OK"}
+		if err := action(info); err != nil {
+			fault := err.(*sc.Fault)
+			status.Status, status.Description = fault.Code, fault.String
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (f *Fake) start(info *sc.ProcessInfo) error {
+	if info.State.IsRunning() {
+		return Fault(sc.StatusAlreadyStarted)
+	}
+	now := int(time.Now().Unix())
+	f.nextPid++
+	info.State, info.StateName = sc.ProcessRunning, sc.ProcessRunning.String()
+	info.Pid, info.Start, info.Now = f.nextPid, now, now
+	return nil
+}
+
+func (f *Fake) stop(info *sc.ProcessInfo) error {
+	if !info.State.IsRunning() {
+		return Fault(sc.StatusNotRunning)
+	}
+	now := int(time.Now().Unix())
+	info.State, info.StateName = sc.ProcessStopped, sc.ProcessStopped.String()
+	info.Pid, info.Stop, info.Now = 0, now, now
+	return nil
+}
+
+func (f *Fake) signal(info *sc.ProcessInfo, signal sc.Signal) error {
+	if !signal.Valid() {
+		return Fault(sc.StatusBadSignal)
+	}
+	if !info.State.IsRunning() {
+		return Fault(sc.StatusNotRunning)
+	}
+	return nil
+}
+
+func (f *Fake) Close() error {
+	return nil
+}
+
+func (f *Fake) ListMethods() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ListMethods"); err != nil {
+		return nil, err
+	}
+	if f.methods != nil {
+		return append([]string(nil), f.methods...), nil
+	}
+	return append([]string(nil), defaultMethods...), nil
+}
+
+func (f *Fake) MethodHelp(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "", f.begin("MethodHelp", name)
+}
+
+func (f *Fake) MethodSignature(name string) ([]sc.MethodSignature, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return nil, f.begin("MethodSignature", name)
+}
+
+func (f *Fake) Capabilities() (*sc.Capabilities, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("Capabilities"); err != nil {
+		return nil, err
+	}
+	methods := f.methods
+	if methods == nil {
+		methods = defaultMethods
+	}
+	return sc.NewCapabilities("3.0", "4.2.5", methods), nil
+}
+
+func (f *Fake) Supports(method string) (bool, error) {
+	caps, err := f.Capabilities()
+	if err != nil {
+		return false, err
+	}
+	return caps.Has(method), nil
+}
+
+func (f *Fake) Call(ns sc.Namespace, method string, args []interface{}, reply interface{}) error {
+	return f.CallMethod(string(ns)+"."+method, args, reply)
+}
+
+func (f *Fake) CallMethod(name string, args []interface{}, reply interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("CallMethod", append([]interface{}{name}, args...)...); err != nil {
+		return err
+	}
+	result, ok := f.results[name]
+	if !ok {
+		return Fault(sc.StatusUnknownMethod)
+	}
+	v := reflect.ValueOf(reply)
+	if result == nil || v.Kind() != reflect.Ptr || !reflect.TypeOf(result).AssignableTo(v.Elem().Type()) {
+		return fmt.Errorf("cannot store %T result of %s in %T", result, name, reply)
+	}
+	v.Elem().Set(reflect.ValueOf(result))
+	return nil
+}
+
+func (f *Fake) Multicall(calls []sc.MulticallCall) ([]sc.MulticallResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("Multicall", calls); err != nil {
+		return nil, err
+	}
+	results := make([]sc.MulticallResult, 0, len(calls))
+	for _, call := range calls {
+		if err := f.faults["CallMethod "+call.MethodName]; err != nil {
+			results = append(results, sc.MulticallResult{Err: err})
+		} else if result, ok := f.results[call.MethodName]; ok {
+			results = append(results, sc.MulticallResult{Value: result})
+		} else {
+			results = append(results, sc.MulticallResult{Err: Fault(sc.StatusUnknownMethod)})
+		}
+	}
+	return results, nil
+}
+
+func (f *Fake) GetAPIVersion() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "3.0", f.begin("GetAPIVersion")
+}
+
+func (f *Fake) GetSupervisorVersion() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "4.2.5", f.begin("GetSupervisorVersion")
+}
+
+func (f *Fake) GetIdentification() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "supervisor", f.begin("GetIdentification")
+}
+
+func (f *Fake) GetState() (sc.ServerState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state, f.begin("GetState")
+}
+
+func (f *Fake) GetPID() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return 1, f.begin("GetPID")
+}
+
+func (f *Fake) ReadLog(offset, length int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ReadLog", offset, length); err != nil {
+		return "", err
+	}
+	return readFile(f.mainLog, offset, length), nil
+}
+
+func (f *Fake) ClearLog() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ClearLog"); err != nil {
+		return false, err
+	}
+	f.mainLog = ""
+	return true, nil
+}
+
+func (f *Fake) Shutdown() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("Shutdown"); err != nil {
+		return err
+	}
+	f.state = sc.ServerState{Code: sc.ServerShutdown, Name: "SHUTDOWN"}
+	return nil
+}
+
+func (f *Fake) Restart() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.begin("Restart")
+}
+
+func (f *Fake) ReloadConfig() ([]string, []string, []string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ReloadConfig"); err != nil {
+		return nil, nil, nil, err
+	}
+	return f.reload[0], f.reload[1], f.reload[2], nil
+}
+
+func (f *Fake) AddProcessGroup(name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("AddProcessGroup", name); err != nil {
+		return false, err
+	}
+	if _, err := f.group(name); err == nil {
+		return false, Fault(sc.StatusAlreadyAdded)
+	}
+	added := false
+	for _, config := range f.configs {
+		if config.Group == name {
+			info := &sc.ProcessInfo{Name: config.Name, Group: config.Group, StateName: sc.ProcessStopped.String()}
+			f.processes[info.FullName()] = info
+			added = true
+		}
+	}
+	if !added {
+		return false, Fault(sc.StatusBadName)
+	}
+	return true, nil
+}
+
+func (f *Fake) RemoveProcessGroup(name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("RemoveProcessGroup", name); err != nil {
+		return false, err
+	}
+	infos, err := f.group(name)
+	if err != nil {
+		return false, err
+	}
+	for _, info := range infos {
+		if info.State.IsRunning() {
+			return false, Fault(sc.StatusStillRunning)
+		}
+	}
+	for _, info := range infos {
+		delete(f.processes, info.FullName())
+	}
+	return true, nil
+}
+
+func (f *Fake) StartProcess(name string, wait bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("StartProcess", name, wait); err != nil {
+		return err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	return f.start(info)
+}
+
+func (f *Fake) StartProcessGroup(name string, wait bool) ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("StartProcessGroup", name, wait); err != nil {
+		return nil, err
+	}
+	infos, err := f.group(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.each("StartProcessGroup", infos, f.start), nil
+}
+
+func (f *Fake) StartAllProcesses(wait bool) ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("StartAllProcesses", wait); err != nil {
+		return nil, err
+	}
+	return f.each("StartAllProcesses", f.all(), f.start), nil
+}
+
+func (f *Fake) StopProcess(name string, wait bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("StopProcess", name, wait); err != nil {
+		return err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	return f.stop(info)
+}
+
+func (f *Fake) StopProcessGroup(name string, wait bool) ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("StopProcessGroup", name, wait); err != nil {
+		return nil, err
+	}
+	infos, err := f.group(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.each("StopProcessGroup", infos, f.stop), nil
+}
+
+func (f *Fake) StopAllProcesses(wait bool) ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("StopAllProcesses", wait); err != nil {
+		return nil, err
+	}
+	return f.each("StopAllProcesses", f.all(), f.stop), nil
+}
+
+func (f *Fake) SignalProcess(name string, signal syscall.Signal) error {
+	return f.signalProcess("SignalProcess", name, signal, sc.Signal(signal))
+}
+
+func (f *Fake) SignalProcessGroup(name string, signal syscall.Signal) ([]sc.ActionStatus, error) {
+	return f.signalProcessGroup("SignalProcessGroup", name, signal, sc.Signal(signal))
+}
+
+func (f *Fake) SignalAllProcesses(signal syscall.Signal) ([]sc.ActionStatus, error) {
+	return f.signalAllProcesses("SignalAllProcesses", signal, sc.Signal(signal))
+}
+
+func (f *Fake) SignalProcessNamed(name string, signal sc.Signal) error {
+	return f.signalProcess("SignalProcessNamed", name, signal, signal)
+}
+
+func (f *Fake) SignalProcessGroupNamed(name string, signal sc.Signal) ([]sc.ActionStatus, error) {
+	return f.signalProcessGroup("SignalProcessGroupNamed", name, signal, signal)
+}
+
+func (f *Fake) SignalAllProcessesNamed(signal sc.Signal) ([]sc.ActionStatus, error) {
+	return f.signalAllProcesses("SignalAllProcessesNamed", signal, signal)
+}
+
+// signalProcess Record arg as passed to method and signal name with signal
+func (f *Fake) signalProcess(method, name string, arg interface{}, signal sc.Signal) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(method, name, arg); err != nil {
+		return err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	return f.signal(info, signal)
+}
+
+func (f *Fake) signalProcessGroup(method, name string, arg interface{}, signal sc.Signal) ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(method, name, arg); err != nil {
+		return nil, err
+	}
+	infos, err := f.group(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.each(method, infos, func(info *sc.ProcessInfo) error {
+		return f.signal(info, signal)
+	}), nil
+}
+
+func (f *Fake) signalAllProcesses(method string, arg interface{}, signal sc.Signal) ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(method, arg); err != nil {
+		return nil, err
+	}
+	return f.each(method, f.all(), func(info *sc.ProcessInfo) error {
+		return f.signal(info, signal)
+	}), nil
+}
+
+func (f *Fake) GetAllConfigInfo() ([]sc.ProgramConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("GetAllConfigInfo"); err != nil {
+		return nil, err
+	}
+	return append([]sc.ProgramConfig(nil), f.configs...), nil
+}
+
+func (f *Fake) GetProcessInfo(name string) (sc.ProcessInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("GetProcessInfo", name); err != nil {
+		return sc.ProcessInfo{}, err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return sc.ProcessInfo{}, err
+	}
+	return *info, nil
+}
+
+func (f *Fake) GetAllProcessInfo() ([]sc.ProcessInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("GetAllProcessInfo"); err != nil {
+		return nil, err
+	}
+	infos := make([]sc.ProcessInfo, 0, len(f.processes))
+	for _, info := range f.all() {
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+func (f *Fake) ReadProcessStdoutLog(name string, offset, length int) (string, error) {
+	return f.readProcessLog("ReadProcessStdoutLog", f.stdout, name, offset, length)
+}
+
+func (f *Fake) ReadProcessStderrLog(name string, offset, length int) (string, error) {
+	return f.readProcessLog("ReadProcessStderrLog", f.stderr, name, offset, length)
+}
+
+func (f *Fake) TailProcessStdoutLog(name string, offset, length int) (*sc.TailResult, error) {
+	return f.tailProcessLog("TailProcessStdoutLog", f.stdout, name, offset, length)
+}
+
+func (f *Fake) TailProcessStderrLog(name string, offset, length int) (*sc.TailResult, error) {
+	return f.tailProcessLog("TailProcessStderrLog", f.stderr, name, offset, length)
+}
+
+func (f *Fake) readProcessLog(method string, logs map[string]string, name string, offset, length int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(method, name, offset, length); err != nil {
+		return "", err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return readFile(logs[info.FullName()], offset, length), nil
+}
+
+func (f *Fake) tailProcessLog(method string, logs map[string]string, name string, offset, length int) (*sc.TailResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(method, name, offset, length); err != nil {
+		return nil, err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	content, end, overflow := tailFile(logs[info.FullName()], offset, length)
+	return &sc.TailResult{Content: content, Offset: int64(end), Overflow: overflow}, nil
+}
+
+func (f *Fake) ClearProcessLogs(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ClearProcessLogs", name); err != nil {
+		return err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	delete(f.stdout, info.FullName())
+	delete(f.stderr, info.FullName())
+	return nil
+}
+
+func (f *Fake) ClearAllProcessLogs() ([]sc.ActionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ClearAllProcessLogs"); err != nil {
+		return nil, err
+	}
+	return f.each("ClearAllProcessLogs", f.all(), func(info *sc.ProcessInfo) error {
+		delete(f.stdout, info.FullName())
+		delete(f.stderr, info.FullName())
+		return nil
+	}), nil
+}
+
+func (f *Fake) SendProcessStdin(name, chars string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("SendProcessStdin", name, chars); err != nil {
+		return err
+	}
+	info, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	if !info.State.IsRunning() {
+		return Fault(sc.StatusNotRunning)
+	}
+	f.stdin[info.FullName()] += chars
+	return nil
+}
+
+// readFile Read like supervisord's readFile, length 0 reads to the end
+func readFile(data string, offset, length int) string {
+	if offset < 0 || offset >= len(data) {
+		return ""
+	}
+	if length <= 0 || offset+length > len(data) {
+		return data[offset:]
+	}
+	return data[offset : offset+length]
+}
+
+// tailFile Tail like supervisord's tailFile: once offset+length passes the end
+// it returns the last length bytes, including bytes before offset
+func tailFile(data string, offset, length int) (string, int, bool) {
+	size, overflow := len(data), false
+	if size > offset+length {
+		overflow, offset = true, size-1
+	}
+	if offset+length > size {
+		if offset > size-1 {
+			length = 0
+		}
+		offset = size - length
+		if offset < 0 {
+			offset = 0
+		}
+		if length < 0 {
+			length = 0
+		}
+	}
+	end := offset + length
+	if end > size {
+		end = size
+	}
+	return data[offset:end], size, overflow
+}
+
+var defaultMethods = []string{
+	"supervisor.addProcessGroup",
+	"supervisor.clearAllProcessLogs",
+	"supervisor.clearLog",
+	"supervisor.clearProcessLogs",
+	"supervisor.getAPIVersion",
+	"supervisor.getAllConfigInfo",
+	"supervisor.getAllProcessInfo",
+	"supervisor.getIdentification",
+	"supervisor.getPID",
+	"supervisor.getProcessInfo",
+	"supervisor.getState",
+	"supervisor.getSupervisorVersion",
+	"supervisor.readLog",
+	"supervisor.readProcessStderrLog",
+	"supervisor.readProcessStdoutLog",
+	"supervisor.reloadConfig",
+	"supervisor.removeProcessGroup",
+	"supervisor.restart",
+	"supervisor.sendProcessStdin",
+	"supervisor.shutdown",
+	"supervisor.signalAllProcesses",
+	"supervisor.signalProcess",
+	"supervisor.signalProcessGroup",
+	"supervisor.startAllProcesses",
+	"supervisor.startProcess",
+	"supervisor.startProcessGroup",
+	"supervisor.stopAllProcesses",
+	"supervisor.stopProcess",
+	"supervisor.stopProcessGroup",
+	"supervisor.tailProcessStderrLog",
+	"supervisor.tailProcessStdoutLog",
+	"system.listMethods",
+	"system.methodHelp",
+	"system.methodSignature",
+	"system.multicall",
+}
@@ -0,0 +1,154 @@
+package supervisortest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sc "github.com/lixianyang/supervisor-client"
+)
+
+func TestFakeProcessLifecycle(t *testing.T) {
+	f := NewFake(sc.ProcessInfo{Name: "web", Group: "web"}, sc.ProcessInfo{Name: "worker_0", Group: "worker"})
+	var client sc.Interface = f
+
+	if err := client.StartProcess("web", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartProcess("web:web", true); !isFault(err, sc.StatusAlreadyStarted) {
+		t.Fatalf("second start: %v", err)
+	}
+	info, err := client.GetProcessInfo("web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State != sc.ProcessRunning || info.Pid == 0 {
+		t.Fatalf("after start: %+v", info)
+	}
+	statuses, err := client.StopAllProcesses(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].Status != sc.StatusSuccess || statuses[1].Status != sc.StatusNotRunning {
+		t.Fatalf("stop all: %+v", statuses)
+	}
+	if _, err := client.GetProcessInfo("missing"); !isFault(err, sc.StatusBadName) {
+		t.Fatalf("missing: %v", err)
+	}
+	if calls := f.CallsTo("StartProcess"); len(calls) != 2 || calls[1].Args[0] != "web:web" {
+		t.Fatalf("calls: %+v", calls)
+	}
+}
+
+func TestFakeProgrammedResults(t *testing.T) {
+	f := NewFake(sc.ProcessInfo{Name: "web", State: sc.ProcessRunning})
+	f.FailProcess("StopProcess", "web", Fault(sc.StatusFailed))
+	if err := f.StopProcess("web", true); !isFault(err, sc.StatusFailed) {
+		t.Fatalf("programmed fault: %v", err)
+	}
+	f.FailProcess("StopProcess", "web", nil)
+	if err := f.StopProcess("web", true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []sc.ActionStatus{{Name: "web", Group: "web", Status: sc.StatusAbnormalTermination}}
+	f.SetStatuses("StartAllProcesses", want)
+	statuses, err := f.StartAllProcesses(false)
+	if err != nil || len(statuses) != 1 || statuses[0].Status != sc.StatusAbnormalTermination {
+		t.Fatalf("programmed statuses: %+v %v", statuses, err)
+	}
+
+	f.Fail("GetState", errors.New("connection refused"))
+	if _, err := f.GetState(); err == nil {
+		t.Fatal("expected the programmed error")
+	}
+}
+
+func TestFakeLogs(t *testing.T) {
+	f := NewFake(sc.ProcessInfo{Name: "web", State: sc.ProcessRunning})
+	f.AppendStdout("web:web", "one\ntwo\nthree\n")
+
+	content, err := f.ReadProcessStdoutLog("web", 4, 4)
+	if err != nil || content != "two\n" {
+		t.Fatalf("read: %q %v", content, err)
+	}
+	tail, err := f.TailProcessStdoutLog("web", 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail.Content != "three\n" || tail.Offset != 14 || !tail.Overflow {
+		t.Fatalf("tail: %+v", tail)
+	}
+	f.AppendStdout("web:web", "four\n")
+	tail, err = f.TailProcessStdoutLog("web", 14, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail.Content != "\nfour\n" || tail.Offset != 19 || tail.Overflow {
+		t.Fatalf("tail clamped to the last length bytes: %+v", tail)
+	}
+	if tail, _ = f.TailProcessStdoutLog("web", 19, 6); tail.Content != "" || tail.Offset != 19 {
+		t.Fatalf("tail without new output: %+v", tail)
+	}
+	if err := f.SendProcessStdin("web", "hello\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Stdin("web:web"); got != "hello\n" {
+		t.Fatalf("stdin: %q", got)
+	}
+}
+
+func TestFakeCallsAndCapabilities(t *testing.T) {
+	f := NewFake()
+	var client sc.Interface = f
+	if ok, err := client.Supports("supervisor.signalProcess"); err != nil || !ok {
+		t.Fatalf("expected signalProcess supported: %t %v", ok, err)
+	}
+	f.SetMethods("supervisor.getState")
+	if ok, _ := client.Supports("supervisor.signalProcess"); ok {
+		t.Fatal("expected signalProcess unsupported after SetMethods")
+	}
+
+	f.SetCallResult("twiddler.getGroupNames", []string{"web"})
+	var groups []string
+	if err := client.Call(sc.TwiddlerNamespace, "getGroupNames", nil, &groups); err != nil || len(groups) != 1 {
+		t.Fatalf("programmed call result: %v %v", groups, err)
+	}
+	if err := client.CallMethod("twiddler.log", nil, new(bool)); !errors.Is(err, sc.ErrUnsupported) {
+		t.Fatalf("expected an unknown method: %v", err)
+	}
+	results, err := client.Multicall([]sc.MulticallCall{{MethodName: "twiddler.getGroupNames"}, {MethodName: "twiddler.log"}})
+	if err != nil || len(results) != 2 || results[0].Err != nil || !isFault(results[1].Err, sc.StatusUnknownMethod) {
+		t.Fatalf("multicall: %+v %v", results, err)
+	}
+}
+
+func isFault(err error, code sc.Status) bool {
+	var fault *sc.Fault
+	return errors.As(err, &fault) && fault.Code == code
+}
+
+func TestFakeWithComponents(t *testing.T) {
+	f := NewFake(sc.ProcessInfo{Name: "web", State: sc.ProcessRunning, Pid: 42})
+	snapshot, err := sc.TakeSnapshot(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopper := sc.NewGracefulStopper(f, sc.StopPolicy{Steps: []sc.EscalationStep{{Signal: sc.SignalTERM, Wait: time.Millisecond}}})
+	stopper.PollInterval = time.Millisecond
+	result, err := stopper.Stop(context.Background(), "web:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Fallback || result.State != sc.ProcessStopped {
+		t.Fatalf("expected the fallback to stop the process: %+v", result)
+	}
+	if len(f.CallsTo("SignalProcessNamed")) != 1 || len(f.CallsTo("StopProcess")) != 1 {
+		t.Fatalf("calls: %+v", f.Calls())
+	}
+	restored, err := sc.RestoreSnapshot(f, snapshot, true)
+	if err != nil || len(restored) != 1 || restored[0].Action != sc.RestoreStart || restored[0].Err != nil {
+		t.Fatalf("expected web:web started again: %+v %v", restored, err)
+	}
+}
@@ -0,0 +1,144 @@
+package supervisor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kolo/xmlrpc"
+)
+
+// testHandler answers one xml rpc method of the test server
+type testHandler func(args []interface{}) (interface{}, error)
+
+// testFault makes the test server answer with an xml rpc fault
+type testFault struct {
+	code   Status
+	string string
+}
+
+func (f testFault) Error() string {
+	return fmt.Sprintf("%d: %s", f.code, f.string)
+}
+
+// testServer a minimal in-process supervisord speaking xml rpc
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	handlers map[string]testHandler
+	calls    []string
+}
+
+func newTestServer(t *testing.T, handlers map[string]testHandler) *testServer {
+	ts := &testServer{handlers: handlers}
+	ts.Server = httptest.NewServer(ts)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	method, args, err := decodeMethodCall(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ts.mu.Lock()
+	ts.calls = append(ts.calls, method)
+	handler, ok := ts.handlers[method]
+	ts.mu.Unlock()
+	w.Header().Set("Content-Type", "text/xml")
+	if !ok {
+		io.WriteString(w, encodeTestFault(testFault{StatusUnknownMethod, "UNKNOWN_METHOD"}))
+		return
+	}
+	result, err := handler(args)
+	if err != nil {
+		fault, ok := err.(testFault)
+		if !ok {
+			fault = testFault{StatusFailed, "FAILED: " + err.Error()}
+		}
+		io.WriteString(w, encodeTestFault(fault))
+		return
+	}
+	response, err := encodeTestResponse(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	io.WriteString(w, response)
+}
+
+// handle Register or replace the handler of method
+func (ts *testServer) handle(method string, handler testHandler) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.handlers[method] = handler
+}
+
+// Calls Return the method names received so far
+func (ts *testServer) Calls() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.calls...)
+}
+
+func (ts *testServer) client(t *testing.T) *Client {
+	cli, err := New(ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+// supervisordTail Answer a tail like supervisord's tailFile: the last length
+// bytes of data once offset+length passes its end, with the new offset
+func supervisordTail(data string, offset, length int) []interface{} {
+	size, overflow := len(data), false
+	if size > offset+length {
+		overflow, offset = true, size-1
+	}
+	if offset+length > size {
+		if offset > size-1 {
+			length = 0
+		}
+		offset = size - length
+		if offset < 0 {
+			offset = 0
+		}
+		if length < 0 {
+			length = 0
+		}
+	}
+	end := offset + length
+	if end > size {
+		end = size
+	}
+	return []interface{}{data[offset:end], size, overflow}
+}
+
+func encodeTestResponse(v interface{}) (string, error) {
+	call, err := xmlrpc.EncodeMethodCall("response", v)
+	if err != nil {
+		return "", err
+	}
+	s := string(call)
+	params := s[strings.Index(s, "<params>"):strings.LastIndex(s, "</params>")]
+	return `<?xml version="1.0"?><methodResponse>` + params + `</params></methodResponse>`, nil
+}
+
+func encodeTestFault(f testFault) string {
+	return fmt.Sprintf(`<?xml version="1.0"?><methodResponse><fault><value><struct>`+
+		`<member><name>faultCode</name><value><int>%d</int></value></member>`+
+		`<member><name>faultString</name><value><string>%s</string></value></member>`+
+		`</struct></value></fault></methodResponse>`, f.code, f.string)
+}
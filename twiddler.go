@@ -0,0 +1,224 @@
+package supervisor
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Twiddler Typed access to the supervisor_twiddler rpcinterface plugin
+type Twiddler struct {
+	client *Client
+}
+
+// Twiddler Return the supervisor_twiddler methods of the client
+func (c *Client) Twiddler() *Twiddler {
+	return &Twiddler{client: c}
+}
+
+// TwiddlerProgramOptions The [program:x] options of a program added at run time.
+// Empty strings and nil values are left out so supervisord defaults apply.
+type TwiddlerProgramOptions struct {
+	Command               string            `twiddler:"command"`
+	ProcessName           string            `twiddler:"process_name"`
+	NumProcs              *int              `twiddler:"numprocs"`
+	NumProcsStart         *int              `twiddler:"numprocs_start"`
+	Priority              *int              `twiddler:"priority"`
+	Autostart             *bool             `twiddler:"autostart"`
+	Autorestart           string            `twiddler:"autorestart"` // true, false or unexpected
+	StartSeconds          *int              `twiddler:"startsecs"`
+	StartRetries          *int              `twiddler:"startretries"`
+	ExitCodes             []int             `twiddler:"exitcodes"`
+	StopSignal            string            `twiddler:"stopsignal"`
+	StopWaitSeconds       *int              `twiddler:"stopwaitsecs"`
+	StopAsGroup           *bool             `twiddler:"stopasgroup"`
+	KillAsGroup           *bool             `twiddler:"killasgroup"`
+	User                  string            `twiddler:"user"`
+	RedirectStderr        *bool             `twiddler:"redirect_stderr"`
+	StdoutLogfile         string            `twiddler:"stdout_logfile"`
+	StdoutLogfileMaxBytes string            `twiddler:"stdout_logfile_maxbytes"` // e.g. 50MB
+	StdoutLogfileBackups  *int              `twiddler:"stdout_logfile_backups"`
+	StdoutCaptureMaxBytes string            `twiddler:"stdout_capture_maxbytes"`
+	StdoutEventsEnabled   *bool             `twiddler:"stdout_events_enabled"`
+	StderrLogfile         string            `twiddler:"stderr_logfile"`
+	StderrLogfileMaxBytes string            `twiddler:"stderr_logfile_maxbytes"`
+	StderrLogfileBackups  *int              `twiddler:"stderr_logfile_backups"`
+	StderrCaptureMaxBytes string            `twiddler:"stderr_capture_maxbytes"`
+	StderrEventsEnabled   *bool             `twiddler:"stderr_events_enabled"`
+	Environment           map[string]string `twiddler:"environment"`
+	Directory             string            `twiddler:"directory"`
+	Umask                 string            `twiddler:"umask"`
+	ServerURL             string            `twiddler:"serverurl"`
+}
+
+// Bool Return a pointer to v for optional options
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Int Return a pointer to v for optional options
+func Int(v int) *int {
+	return &v
+}
+
+// TwiddlerOptions Return the options re-creating the program of pc, as far as
+// getAllConfigInfo reports them. Autostart is off, the caller starts it.
+func (pc ProgramConfig) TwiddlerOptions() TwiddlerProgramOptions {
+	options := TwiddlerProgramOptions{
+		Command:               pc.Command,
+		Priority:              Int(pc.ProcessPriority),
+		Autostart:             Bool(false),
+		StartSeconds:          Int(pc.StartSeconds),
+		StartRetries:          Int(pc.StartRetries),
+		ExitCodes:             pc.ExitCodes,
+		StopWaitSeconds:       Int(pc.StopWaitSeconds),
+		KillAsGroup:           Bool(pc.KillAsGroup),
+		RedirectStderr:        Bool(pc.RedirectStderr),
+		StdoutLogfile:         pc.StdoutLogfile,
+		StdoutLogfileMaxBytes: strconv.FormatInt(pc.StdoutLogfileMaxBytes, 10),
+		StdoutLogfileBackups:  Int(pc.StdoutLogfileBackups),
+		StdoutCaptureMaxBytes: strconv.FormatInt(pc.StdoutCaptureMaxBytes, 10),
+		StdoutEventsEnabled:   Bool(pc.StdoutEventsEnabled),
+		StderrLogfile:         pc.StderrLogfile,
+		StderrLogfileMaxBytes: strconv.FormatInt(pc.StderrLogfileMaxBytes, 10),
+		StderrLogfileBackups:  Int(pc.StderrLogfileBackups),
+		StderrCaptureMaxBytes: strconv.FormatInt(pc.StderrCaptureMaxBytes, 10),
+		StderrEventsEnabled:   Bool(pc.StderrEventsEnabled),
+	}
+	if pc.StopSignal.Valid() {
+		options.StopSignal = pc.StopSignal.String()
+	}
+	return options
+}
+
+// Map Return the options as the string map twiddler expects. An environment
+// value supervisord's shlex parsing cannot reproduce is an error.
+func (o TwiddlerProgramOptions) Map() (map[string]string, error) {
+	options := make(map[string]string)
+	v := reflect.ValueOf(o)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("twiddler")
+		switch field := v.Field(i).Interface().(type) {
+		case string:
+			if field != "" {
+				options[key] = field
+			}
+		case *int:
+			if field != nil {
+				options[key] = strconv.Itoa(*field)
+			}
+		case *bool:
+			if field != nil {
+				options[key] = strconv.FormatBool(*field)
+			}
+		case []int:
+			if len(field) > 0 {
+				codes := make([]string, 0, len(field))
+				for _, code := range field {
+					codes = append(codes, strconv.Itoa(code))
+				}
+				options[key] = strings.Join(codes, ",")
+			}
+		case map[string]string:
+			if len(field) > 0 {
+				env, err := formatEnvironment(field)
+				if err != nil {
+					return nil, err
+				}
+				options[key] = env
+			}
+		}
+	}
+	return options, nil
+}
+
+// formatEnvironment Format KEY="value" pairs sorted by key
+func formatEnvironment(env map[string]string) (string, error) {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, err := shlexQuote(env[key])
+		if err != nil {
+			return "", fmt.Errorf("environment %s: %w", key, err)
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ","), nil
+}
+
+// shlexQuote Quote s for supervisord's non-POSIX shlex, which has no escapes:
+// a quoted token runs to the next matching quote and the quotes are then
+// stripped from both ends of the value.
+func shlexQuote(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") ||
+		strings.HasSuffix(s, `"`) || strings.HasSuffix(s, "'") {
+		return "", fmt.Errorf("value %q starts or ends with a quote", s)
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`, nil
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'", nil
+	}
+	return "", fmt.Errorf("value %q contains both quote characters", s)
+}
+
+// Installed Report whether the server has the twiddler plugin
+func (t *Twiddler) Installed() (bool, error) {
+	caps, err := t.client.Capabilities()
+	if err != nil {
+		return false, err
+	}
+	return caps.HasNamespace(TwiddlerNamespace), nil
+}
+
+// GetAPIVersion Return the version of the twiddler API
+func (t *Twiddler) GetAPIVersion() (string, error) {
+	var version string
+	err := t.client.call(TwiddlerNamespace, "getAPIVersion", nil, &version)
+	return version, err
+}
+
+// GetGroupNames Return the names of all process groups
+func (t *Twiddler) GetGroupNames() ([]string, error) {
+	names := make([]string, 0)
+	err := t.client.call(TwiddlerNamespace, "getGroupNames", nil, &names)
+	return names, err
+}
+
+// AddProgramToGroup Add a program to an existing group without editing the config file
+// string group The group name
+// string program The program name, as in [program:x]
+func (t *Twiddler) AddProgramToGroup(group, program string, options TwiddlerProgramOptions) error {
+	values, err := options.Map()
+	if err != nil {
+		return err
+	}
+	var flag bool
+	args := []interface{}{group, program, values}
+	err = t.client.call(TwiddlerNamespace, "addProgramToGroup", args, &flag)
+	return err
+}
+
+// RemoveProcessFromGroup Remove a stopped process from a group
+func (t *Twiddler) RemoveProcessFromGroup(group, process string) error {
+	var flag bool
+	args := []interface{}{group, process}
+	err := t.client.call(TwiddlerNamespace, "removeProcessFromGroup", args, &flag)
+	return err
+}
+
+// Log Write message to the supervisord main log at level, e.g. INFO or ERROR
+func (t *Twiddler) Log(message, level string) error {
+	var flag bool
+	args := []interface{}{message, level}
+	err := t.client.call(TwiddlerNamespace, "log", args, &flag)
+	return err
+}
@@ -0,0 +1,85 @@
+package supervisor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTwiddler(t *testing.T) {
+	var added map[string]interface{}
+	ts := newTestServer(t, map[string]testHandler{
+		"system.listMethods": func(args []interface{}) (interface{}, error) {
+			return []string{"twiddler.getGroupNames", "twiddler.addProgramToGroup"}, nil
+		},
+		"supervisor.getAPIVersion": func(args []interface{}) (interface{}, error) {
+			return "3.0", nil
+		},
+		"supervisor.getSupervisorVersion": func(args []interface{}) (interface{}, error) {
+			return "4.2.2", nil
+		},
+		"twiddler.getGroupNames": func(args []interface{}) (interface{}, error) {
+			return []string{"web", "workers"}, nil
+		},
+		"twiddler.addProgramToGroup": func(args []interface{}) (interface{}, error) {
+			added = args[2].(map[string]interface{})
+			return true, nil
+		},
+	})
+	twiddler := ts.client(t).Twiddler()
+	if ok, err := twiddler.Installed(); err != nil || !ok {
+		t.Fatalf("expected twiddler installed but %t %v", ok, err)
+	}
+	groups, err := twiddler.GetGroupNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(groups, []string{"web", "workers"}) {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	err = twiddler.AddProgramToGroup("workers", "worker_3", TwiddlerProgramOptions{
+		Command:      "/usr/bin/worker --id 3",
+		Autostart:    Bool(false),
+		StartSeconds: Int(0),
+		ExitCodes:    []int{0, 2},
+		Environment:  map[string]string{"B": "2", "A": "x y"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"command":     "/usr/bin/worker --id 3",
+		"autostart":   "false",
+		"startsecs":   "0",
+		"exitcodes":   "0,2",
+		"environment": `A="x y",B="2"`,
+	}
+	if !reflect.DeepEqual(added, expected) {
+		t.Fatalf("expected options %v but %v", expected, added)
+	}
+}
+
+func TestTwiddlerProgramOptionsMap(t *testing.T) {
+	options, err := TwiddlerProgramOptions{
+		ExitCodes: []int{},
+		Environment: map[string]string{
+			"DIR":  `C:\tmp`,
+			"JSON": `{"a": 1}`,
+			"MSG":  "it's",
+		},
+	}.Map()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"environment": `DIR="C:\tmp",JSON='{"a": 1}',MSG="it's"`,
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Fatalf("expected options %v but %v", expected, options)
+	}
+	for _, value := range []string{`say "it's"`, `"quoted"`} {
+		options := TwiddlerProgramOptions{Environment: map[string]string{"A": value}}
+		if _, err := options.Map(); err == nil {
+			t.Fatalf("expected %s rejected", value)
+		}
+	}
+}
@@ -6,14 +6,15 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
-	"syscall"
+	"time"
 )
 
 type Namespace string
 
 const (
-	SystemNamespace  Namespace = "system"
-	DefaultNamespace Namespace = "supervisor"
+	SystemNamespace   Namespace = "system"
+	DefaultNamespace  Namespace = "supervisor"
+	TwiddlerNamespace Namespace = "twiddler"
 )
 
 type Status int
@@ -66,40 +67,152 @@ var (
 	ErrIncorrectParameters = errors.New("INCORRECT_PARAMETERS")
 	ErrNotRunning          = errors.New("NOT_RUNNING")
 	ErrNoFile              = errors.New("NO_FILE")
+	ErrUnsupported         = errors.New("UNSUPPORTED")
+	ErrBadSignal           = errors.New("BAD_SIGNAL")
 )
 
+// Fault An xml rpc fault returned by supervisord
+type Fault struct {
+	Code   Status
+	String string
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("Fault(%d): %s", f.Code, f.String)
+}
+
+// Is Match the sentinel error of the fault code, e.g. errors.Is(err, ErrNotRunning)
+func (f *Fault) Is(target error) bool {
+	switch target {
+	case ErrIncorrectParameters:
+		return f.Code == StatusIncorrectParameters
+	case ErrNotRunning:
+		return f.Code == StatusNotRunning
+	case ErrNoFile:
+		return f.Code == StatusNoFile
+	case ErrUnsupported:
+		return f.Code == StatusUnknownMethod
+	case ErrBadSignal:
+		return f.Code == StatusBadSignal
+	}
+	return false
+}
+
+// UnsupportedError The server does not provide the method, reported before it is called
+type UnsupportedError struct {
+	Method string
+}
+
+func (e *UnsupportedError) Error() string {
+	return "method " + e.Method + " is not supported by the server"
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return ErrUnsupported
+}
+
 type ServerState struct {
 	Code State  `xmlrpc:"statecode"`
 	Name string `xmlrpc:"statename"`
 }
 
 type ActionStatus struct {
-	Name        string `xmlrpc:"name"`
-	Group       string `xmlrpc:"group"`
-	Status      Status `xmlrpc:"status"`
-	Description string `xmlrpc:"description"`
+	Name        string `xmlrpc:"name" json:"name" yaml:"name"`
+	Group       string `xmlrpc:"group" json:"group" yaml:"group"`
+	Status      Status `xmlrpc:"status" json:"status" yaml:"status"`
+	Description string `xmlrpc:"description" json:"description" yaml:"description"`
 }
 
 type ProcessInfo struct {
-	Name          string       `xmlrpc:"name"`
-	Group         string       `xmlrpc:"group"`
-	Start         int          `xmlrpc:"start"`
-	Stop          int          `xmlrpc:"stop"`
-	Now           int          `xmlrpc:"now"`
-	State         ProcessState `xmlrpc:"state"`
-	StateName     string       `xmlrpc:"statename"`
-	SpawnErr      string       `xmlrpc:"spawnerr"`
-	ExitStatus    int          `xmlrpc:"exitstatus"`
-	Logfile       string       `xmlrpc:"logfile"`
-	StdoutLogfile string       `xmlrpc:"stdout_logfile"`
-	StderrLogfile string       `xmlrpc:"stderr_logfile"`
-	Pid           int          `xmlrpc:"pid"`
+	Name          string       `xmlrpc:"name" json:"name" yaml:"name"`
+	Group         string       `xmlrpc:"group" json:"group" yaml:"group"`
+	Start         int          `xmlrpc:"start" json:"start" yaml:"start"`
+	Stop          int          `xmlrpc:"stop" json:"stop" yaml:"stop"`
+	Now           int          `xmlrpc:"now" json:"now" yaml:"now"`
+	State         ProcessState `xmlrpc:"state" json:"state" yaml:"state"`
+	StateName     string       `xmlrpc:"statename" json:"statename" yaml:"statename"`
+	SpawnErr      string       `xmlrpc:"spawnerr" json:"spawnerr" yaml:"spawnerr"`
+	ExitStatus    int          `xmlrpc:"exitstatus" json:"exitstatus" yaml:"exitstatus"`
+	Logfile       string       `xmlrpc:"logfile" json:"logfile" yaml:"logfile"`
+	StdoutLogfile string       `xmlrpc:"stdout_logfile" json:"stdout_logfile" yaml:"stdout_logfile"`
+	StderrLogfile string       `xmlrpc:"stderr_logfile" json:"stderr_logfile" yaml:"stderr_logfile"`
+	Pid           int          `xmlrpc:"pid" json:"pid" yaml:"pid"`
 }
 
 func (pi ProcessInfo) String() string {
 	return printStruct(pi)
 }
 
+// FullName Return the group:name used to address the process
+func (pi ProcessInfo) FullName() string {
+	return pi.Group + ":" + pi.Name
+}
+
+// StartedAt Return when the process was last started, zero if never
+func (pi ProcessInfo) StartedAt() time.Time {
+	return unixTime(pi.Start)
+}
+
+// StoppedAt Return when the process last stopped, zero if never
+func (pi ProcessInfo) StoppedAt() time.Time {
+	return unixTime(pi.Stop)
+}
+
+// ServerTime Return the time of supervisord when the info was taken
+func (pi ProcessInfo) ServerTime() time.Time {
+	return unixTime(pi.Now)
+}
+
+// Uptime Return how long a RUNNING process has been up, measured against the
+// server's Now so the local clock does not matter
+func (pi ProcessInfo) Uptime() time.Duration {
+	if pi.State != ProcessRunning || pi.Start == 0 || pi.Now < pi.Start {
+		return 0
+	}
+	return time.Duration(pi.Now-pi.Start) * time.Second
+}
+
+// Describe Return the description supervisorctl status shows, e.g. "pid 123, uptime 0:01:02"
+func (pi ProcessInfo) Describe() string {
+	switch pi.State {
+	case ProcessRunning:
+		return fmt.Sprintf("pid %d, uptime %s", pi.Pid, formatUptime(pi.Uptime()))
+	case ProcessFatal, ProcessBackoff:
+		if pi.SpawnErr != "" {
+			return pi.SpawnErr
+		}
+		return fmt.Sprintf("unknown error (try \"tail %s\")", pi.Name)
+	case ProcessStopped, ProcessExited:
+		if pi.Start == 0 {
+			return "Not started"
+		}
+		return pi.StoppedAt().Local().Format("Jan 02 03:04 PM")
+	}
+	return ""
+}
+
+func unixTime(sec int) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(sec), 0)
+}
+
+// formatUptime Format like python's timedelta, e.g. "0:01:02" or "2 days, 3:04:05"
+func formatUptime(d time.Duration) string {
+	total := int(d / time.Second)
+	days := total / 86400
+	total %= 86400
+	clock := fmt.Sprintf("%d:%02d:%02d", total/3600, total%3600/60, total%60)
+	switch days {
+	case 0:
+		return clock
+	case 1:
+		return "1 day, " + clock
+	}
+	return fmt.Sprintf("%d days, %s", days, clock)
+}
+
 type TailResult struct {
 	Content  string
 	Offset   int64
@@ -107,30 +220,30 @@ type TailResult struct {
 }
 
 type ProgramConfig struct {
-	Name                  string         `xmlrpc:"name"`
-	Group                 string         `xmlrpc:"group"`
-	Command               string         `xmlrpc:"command"`
-	InUse                 bool           `xmlrpc:"inuse"`
-	Autostart             bool           `xmlrpc:"autostart"`
-	StartSeconds          int            `xmlrpc:"startsecs"`
-	StartRetries          int            `xmlrpc:"startretries"`
-	StopSignal            syscall.Signal `xmlrpc:"stopsignal"`
-	StopWaitSeconds       int            `xmlrpc:"stopwaitsecs"`
-	RedirectStderr        bool           `xmlrpc:"redirect_stderr"`
-	ExitCodes             []int          `xmlrpc:"exitcodes"`
-	ProcessPriority       int            `xmlrpc:"process_prio"`
-	GroupPriority         int            `xmlrpc:"group_prio"`
-	KillAsGroup           bool           `xmlrpc:"killasgroup"`
-	StdoutLogfile         string         `xmlrpc:"stdout_logfile"`
-	StderrLogfile         string         `xmlrpc:"stderr_logfile"`
-	StderrLogfileBackups  int            `xmlrpc:"stderr_logfile_backups"`
-	StdoutLogfileBackups  int            `xmlrpc:"stdout_logfile_backups"`
-	StdoutLogfileMaxBytes int64          `xmlrpc:"stdout_logfile_maxbytes"`
-	StderrLogfileMaxBytes int64          `xmlrpc:"stderr_logfile_maxbytes"`
-	StdoutCaptureMaxBytes int64          `xmlrpc:"stdout_capture_maxbytes"`
-	StderrCaptureMaxBytes int64          `xmlrpc:"stderr_capture_maxbytes"`
-	StdoutEventsEnabled   bool           `xmlrpc:"stdout_events_enabled"`
-	StderrEventsEnabled   bool           `xmlrpc:"stderr_events_enabled"`
+	Name                  string `xmlrpc:"name" json:"name" yaml:"name"`
+	Group                 string `xmlrpc:"group" json:"group" yaml:"group"`
+	Command               string `xmlrpc:"command" json:"command" yaml:"command"`
+	InUse                 bool   `xmlrpc:"inuse" json:"inuse" yaml:"inuse"`
+	Autostart             bool   `xmlrpc:"autostart" json:"autostart" yaml:"autostart"`
+	StartSeconds          int    `xmlrpc:"startsecs" json:"startsecs" yaml:"startsecs"`
+	StartRetries          int    `xmlrpc:"startretries" json:"startretries" yaml:"startretries"`
+	StopSignal            Signal `xmlrpc:"stopsignal" json:"stopsignal" yaml:"stopsignal"`
+	StopWaitSeconds       int    `xmlrpc:"stopwaitsecs" json:"stopwaitsecs" yaml:"stopwaitsecs"`
+	RedirectStderr        bool   `xmlrpc:"redirect_stderr" json:"redirect_stderr" yaml:"redirect_stderr"`
+	ExitCodes             []int  `xmlrpc:"exitcodes" json:"exitcodes" yaml:"exitcodes"`
+	ProcessPriority       int    `xmlrpc:"process_prio" json:"process_prio" yaml:"process_prio"`
+	GroupPriority         int    `xmlrpc:"group_prio" json:"group_prio" yaml:"group_prio"`
+	KillAsGroup           bool   `xmlrpc:"killasgroup" json:"killasgroup" yaml:"killasgroup"`
+	StdoutLogfile         string `xmlrpc:"stdout_logfile" json:"stdout_logfile" yaml:"stdout_logfile"`
+	StderrLogfile         string `xmlrpc:"stderr_logfile" json:"stderr_logfile" yaml:"stderr_logfile"`
+	StderrLogfileBackups  int    `xmlrpc:"stderr_logfile_backups" json:"stderr_logfile_backups" yaml:"stderr_logfile_backups"`
+	StdoutLogfileBackups  int    `xmlrpc:"stdout_logfile_backups" json:"stdout_logfile_backups" yaml:"stdout_logfile_backups"`
+	StdoutLogfileMaxBytes int64  `xmlrpc:"stdout_logfile_maxbytes" json:"stdout_logfile_maxbytes" yaml:"stdout_logfile_maxbytes"`
+	StderrLogfileMaxBytes int64  `xmlrpc:"stderr_logfile_maxbytes" json:"stderr_logfile_maxbytes" yaml:"stderr_logfile_maxbytes"`
+	StdoutCaptureMaxBytes int64  `xmlrpc:"stdout_capture_maxbytes" json:"stdout_capture_maxbytes" yaml:"stdout_capture_maxbytes"`
+	StderrCaptureMaxBytes int64  `xmlrpc:"stderr_capture_maxbytes" json:"stderr_capture_maxbytes" yaml:"stderr_capture_maxbytes"`
+	StdoutEventsEnabled   bool   `xmlrpc:"stdout_events_enabled" json:"stdout_events_enabled" yaml:"stdout_events_enabled"`
+	StderrEventsEnabled   bool   `xmlrpc:"stderr_events_enabled" json:"stderr_events_enabled" yaml:"stderr_events_enabled"`
 }
 
 func (pc ProgramConfig) String() string {
@@ -0,0 +1,51 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestProcessInfoTimes(t *testing.T) {
+	info := ProcessInfo{Name: "web", Group: "web", State: ProcessRunning, Pid: 123, Start: 1000, Now: 1062}
+	if info.Uptime() != 62*time.Second {
+		t.Fatalf("unexpected uptime %s", info.Uptime())
+	}
+	if !info.StartedAt().Equal(time.Unix(1000, 0)) || !info.StoppedAt().IsZero() {
+		t.Fatalf("unexpected timestamps %s %s", info.StartedAt(), info.StoppedAt())
+	}
+	if d := info.Describe(); d != "pid 123, uptime 0:01:02" {
+		t.Fatalf("unexpected description %q", d)
+	}
+	info.Now = info.Start + 2*86400 + 3*3600 + 4*60 + 5
+	if d := info.Describe(); d != "pid 123, uptime 2 days, 3:04:05" {
+		t.Fatalf("unexpected description %q", d)
+	}
+	info = ProcessInfo{Name: "web", State: ProcessFatal}
+	if d := info.Describe(); d != `unknown error (try "tail web")` {
+		t.Fatalf("unexpected description %q", d)
+	}
+	info = ProcessInfo{Name: "web", State: ProcessStopped}
+	if d := info.Describe(); d != "Not started" {
+		t.Fatalf("unexpected description %q", d)
+	}
+}
+
+func TestProcessInfoJSON(t *testing.T) {
+	info := ProcessInfo{Name: "web", Group: "web", State: ProcessRunning, StateName: "RUNNING", Pid: 123}
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["state"] != "RUNNING" || decoded["pid"] != float64(123) || decoded["stdout_logfile"] != "" {
+		t.Fatalf("unexpected encoding %s", data)
+	}
+	var back ProcessInfo
+	if err := json.Unmarshal(data, &back); err != nil || back != info {
+		t.Fatalf("round trip failed: %+v %v", back, err)
+	}
+}
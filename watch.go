@@ -0,0 +1,118 @@
+package supervisor
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Transition A change of one process observed between two polls of GetAllProcessInfo
+type Transition struct {
+	Name    string      // group:name
+	From    ProcessInfo // zero value when Added
+	To      ProcessInfo // zero value when Removed
+	Added   bool
+	Removed bool
+}
+
+// Restarted Report whether the process was spawned again between the two polls
+func (t Transition) Restarted() bool {
+	if t.Added || t.Removed {
+		return false
+	}
+	if t.To.Start != 0 && t.To.Start != t.From.Start {
+		return true
+	}
+	return t.To.Pid != 0 && t.To.Pid != t.From.Pid
+}
+
+// Valid Report whether supervisord can move the process From -> To in one step.
+// Polling can miss intermediate states, e.g. STARTING -> RUNNING -> EXITED shows
+// as STARTING -> EXITED, so an invalid transition is either a missed state or a
+// broken server.
+func (t Transition) Valid() bool {
+	if t.Added || t.Removed {
+		return true
+	}
+	return t.From.State.CanTransitionTo(t.To.State)
+}
+
+// Watcher Poll GetAllProcessInfo and report process transitions
+type Watcher struct {
+	client ReadOnly
+	last   map[string]ProcessInfo
+}
+
+// NewWatcher Create a watcher, the first poll only records a baseline
+func NewWatcher(client ReadOnly) *Watcher {
+	return &Watcher{client: client}
+}
+
+// Poll Fetch all process info and return the transitions since the previous poll
+func (w *Watcher) Poll() ([]Transition, error) {
+	infos, err := w.client.GetAllProcessInfo()
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]ProcessInfo, len(infos))
+	for _, info := range infos {
+		current[info.FullName()] = info
+	}
+	previous := w.last
+	w.last = current
+	if previous == nil {
+		return nil, nil
+	}
+	return diffProcessInfo(previous, current), nil
+}
+
+// Processes Return the process info of the latest poll keyed by group:name
+func (w *Watcher) Processes() map[string]ProcessInfo {
+	processes := make(map[string]ProcessInfo, len(w.last))
+	for name, info := range w.last {
+		processes[name] = info
+	}
+	return processes
+}
+
+// Run Poll every interval and pass non-empty transitions to handle until ctx is done
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, handle func([]Transition)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		transitions, err := w.Poll()
+		if err != nil {
+			return err
+		}
+		if len(transitions) > 0 {
+			handle(transitions)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func diffProcessInfo(previous, current map[string]ProcessInfo) []Transition {
+	transitions := make([]Transition, 0)
+	for name, to := range current {
+		from, ok := previous[name]
+		switch {
+		case !ok:
+			transitions = append(transitions, Transition{Name: name, To: to, Added: true})
+		case from.State != to.State || from.Pid != to.Pid || from.Start != to.Start:
+			transitions = append(transitions, Transition{Name: name, From: from, To: to})
+		}
+	}
+	for name, from := range previous {
+		if _, ok := current[name]; !ok {
+			transitions = append(transitions, Transition{Name: name, From: from, Removed: true})
+		}
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].Name < transitions[j].Name
+	})
+	return transitions
+}